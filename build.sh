@@ -10,6 +10,6 @@ echo "Running tests..."
 go test -v ./...
 
 echo "Building binaries..."
-go build -v -o babilema ./cmd/babilema/main.go
+go build -v -o babilema ./cmd/babilema
 
 echo "Successfully built the project, run it by executing ./babilema"
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ByteBakersCo/babilema/internal/config"
+	"github.com/ByteBakersCo/babilema/internal/generator"
+	"github.com/ByteBakersCo/babilema/internal/highlight"
+	"github.com/ByteBakersCo/babilema/internal/history"
+	"github.com/ByteBakersCo/babilema/internal/parser"
+	"github.com/ByteBakersCo/babilema/internal/scaffold"
+	"github.com/ByteBakersCo/babilema/internal/server"
+	"github.com/ByteBakersCo/babilema/internal/utils"
+)
+
+func initSite(args []string) {
+	flags := flag.NewFlagSet("init", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: babilema init [flags] [dir]")
+		flags.PrintDefaults()
+	}
+
+	overwrite := flags.Bool(
+		"force",
+		false,
+		"Overwrite existing files",
+	)
+
+	flags.Parse(args)
+
+	dir := flags.Arg(0)
+	if dir == "" {
+		dir = "."
+	}
+
+	err := scaffold.Init(dir, *overwrite)
+	if err != nil {
+		log.Fatalln("Error creating starter site:", err)
+	}
+
+	fmt.Println("Starter site created in", dir)
+}
+
+func build(args []string) {
+	flags, configFilePath := newFlagSet("build")
+	force := flags.Bool(
+		"force",
+		false,
+		"Regenerate every blog post, ignoring the history file",
+	)
+
+	dryRun := flags.Bool(
+		"dry-run",
+		false,
+		"Report what would be generated or deleted without writing anything",
+	)
+
+	var only listFlag
+	flags.Var(
+		&only,
+		"only",
+		"Only regenerate the given posts (slug or issue number, repeatable)",
+	)
+
+	flags.Parse(args)
+
+	cfg := loadConfig(*configFilePath)
+
+	postsHistory, err := history.ParseHistoryFile(cfg)
+	if err != nil {
+		log.Fatalln("Error parsing history file:", err)
+	}
+
+	parsedIssues, nextHistory, err := parser.ParseIssues(
+		cfg,
+		postsHistory,
+		parser.Options{Force: *force, Only: only},
+	)
+	if err != nil {
+		log.Fatalln("Error parsing issues:", err)
+	}
+
+	if *dryRun {
+		err = generator.DryRun(
+			parsedIssues,
+			postsHistory,
+			nextHistory,
+			cfg,
+			os.Stdout,
+		)
+		if err != nil {
+			log.Fatalln("Dry run failed:", err)
+		}
+
+		return
+	}
+
+	err = generator.GenerateBlogPosts(
+		parsedIssues,
+		postsHistory,
+		nextHistory,
+		cfg,
+		nil,
+	)
+	if err != nil {
+		log.Fatalln("Error generating blog posts:", err)
+	}
+}
+
+func serve(args []string) {
+	flags, configFilePath := newFlagSet("serve")
+	addr := flags.String(
+		"addr",
+		"localhost:8080",
+		"Address to serve the blog on",
+	)
+
+	flags.Parse(args)
+
+	cfg := loadConfig(*configFilePath)
+
+	err := server.Serve(cfg, *addr)
+	if err != nil {
+		log.Fatalln("Error serving blog:", err)
+	}
+}
+
+func validate(args []string) {
+	flags, configFilePath := newFlagSet("validate")
+	flags.Parse(args)
+
+	cfg := loadConfig(*configFilePath)
+
+	var problems []string
+	for _, err := range config.Validate(cfg) {
+		problems = append(problems, err.Error())
+	}
+
+	if cfg.HighlightStyle != "" && !highlight.HasStyle(cfg.HighlightStyle) {
+		problems = append(problems, fmt.Sprintf(
+			"highlight_style: unknown style %q (available: %s)",
+			cfg.HighlightStyle,
+			strings.Join(highlight.Styles(), ", "),
+		))
+	}
+
+	posts, err := parser.ParseLocalPosts(cfg)
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	// Issues can only be checked when the GitHub environment is available
+	if os.Getenv("GITHUB_TOKEN") != "" {
+		// Only the issues are downloaded, not the images to self-host
+		noFetch := func(url string) ([]byte, error) {
+			return nil, errors.New("images are not downloaded by validate")
+		}
+
+		issues, _, err := parser.ParseIssues(
+			cfg,
+			history.New(),
+			parser.Options{Force: true, Fetch: noFetch},
+		)
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+
+		posts = append(posts, issues...)
+	} else {
+		log.Println("GITHUB_TOKEN not set, only local posts are checked.")
+	}
+
+	problems = append(problems, generator.Validate(posts, cfg)...)
+
+	if len(problems) > 0 {
+		fmt.Printf("Problems (%d):\n", len(problems))
+		for _, problem := range problems {
+			fmt.Printf("  - %s\n", problem)
+		}
+
+		os.Exit(1)
+	}
+
+	fmt.Printf("Everything looks good (%d posts checked).\n", len(posts))
+}
+
+// checkSlug makes sure the slug can name a file of the posts directory.
+func checkSlug(slug string) error {
+	switch {
+	case slug == "":
+		return errors.New("empty slug, set one with -slug")
+	case strings.ContainsAny(slug, `/\`) || strings.Contains(slug, ".."):
+		return fmt.Errorf("%q cannot contain path separators or ..", slug)
+	}
+
+	return nil
+}
+
+func newPost(args []string) {
+	flags, configFilePath := newFlagSet("new")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: babilema new [flags] <title>")
+		flags.PrintDefaults()
+	}
+
+	isIssue := flags.Bool(
+		"issue",
+		false,
+		"Print the issue title and body instead of writing a local post file",
+	)
+
+	slug := flags.String(
+		"slug",
+		"",
+		"Slug of the post (default: generated from the title)",
+	)
+
+	flags.Parse(args)
+
+	title := strings.Join(flags.Args(), " ")
+	if title == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	if *slug == "" {
+		*slug = utils.Slugify(title)
+	}
+
+	err := checkSlug(*slug)
+	if err != nil {
+		log.Fatalln("Invalid slug:", err)
+	}
+
+	cfg := loadConfig(*configFilePath)
+	body := parser.NewPostBody(title, *slug)
+
+	if *isIssue {
+		fmt.Printf("%s %s\n\n%s", cfg.BlogPostIssuePrefix, title, body)
+		return
+	}
+
+	err = os.MkdirAll(cfg.PostsDir, os.ModePerm)
+	if err != nil {
+		log.Fatalln("Error creating posts directory:", err)
+	}
+
+	path := filepath.Join(cfg.PostsDir, *slug+".md")
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		log.Fatalln("Post already exists:", path)
+	}
+
+	err = os.WriteFile(path, []byte(body), 0644)
+	if err != nil {
+		log.Fatalln("Error writing post:", err)
+	}
+
+	fmt.Println("Created", path)
+}
+
+func clean(args []string) {
+	flags, configFilePath := newFlagSet("clean")
+	flags.Parse(args)
+
+	cfg := loadConfig(*configFilePath)
+
+	postsHistory, err := history.ParseHistoryFile(cfg)
+	if err != nil {
+		log.Fatalln("Error parsing history file:", err)
+	}
+
+	err = generator.Clean(postsHistory, cfg)
+	if err != nil {
+		log.Fatalln("Error cleaning generated files:", err)
+	}
+}
@@ -2,43 +2,106 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"log"
+	"os"
+	"strings"
 
 	"github.com/ByteBakersCo/babilema/internal/config"
-	"github.com/ByteBakersCo/babilema/internal/generator"
-	"github.com/ByteBakersCo/babilema/internal/parser"
 )
 
-func main() {
-	configFilePath := flag.String(
+const usage string = `Usage: babilema [command] [flags]
+
+Commands:
+  init      Create a starter site (config, templates, CSS and workflow)
+  build     Generate the blog from the repository's issues (default)
+  serve     Preview the blog locally, with live reload
+  validate  Check the config, templates and posts
+  new       Scaffold a new blog post
+  clean     Remove the generated files
+
+Run 'babilema <command> -h' to see the flags of a command.
+`
+
+type command func(args []string)
+
+var commands = map[string]command{
+	"init":     initSite,
+	"build":    build,
+	"serve":    serve,
+	"validate": validate,
+	"new":      newPost,
+	"clean":    clean,
+}
+
+// listFlag collects the values of a flag that can be repeated or given as a
+// comma-separated list.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+
+	return nil
+}
+
+// newFlagSet returns the flags of the given command, including the -config
+// flag shared by every command.
+func newFlagSet(name string) (*flag.FlagSet, *string) {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	configFilePath := flags.String(
 		"config",
 		"",
 		"Path to the config file",
 	)
 
-	flag.Parse()
+	return flags, configFilePath
+}
 
-	if *configFilePath == "" {
+func loadConfig(configFilePath string) config.Config {
+	if configFilePath == "" {
 		defaultCfgPath, err := config.DefaultConfigPath()
 		if err != nil {
-			return
+			log.Fatalln("Error finding config file:", err)
 		}
 
-		*configFilePath = defaultCfgPath
+		configFilePath = defaultCfgPath
 	}
 
-	cfg, err := config.LoadConfig(*configFilePath)
+	cfg, err := config.LoadConfig(configFilePath)
 	if err != nil {
 		log.Fatalln("Error loading config:", err)
 	}
 
-	parsedIssues, err := parser.ParseIssues(cfg)
-	if err != nil {
-		log.Fatalln("Error parsing issues:", err)
+	return cfg
+}
+
+func main() {
+	name := "build"
+	args := os.Args[1:]
+
+	// Running without a command (e.g. `babilema --config ...`) builds the blog
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
 	}
 
-	err = generator.GenerateBlogPosts(parsedIssues, cfg, nil)
-	if err != nil {
-		log.Fatalln("Error generating blog posts:", err)
+	if name == "help" {
+		fmt.Print(usage)
+		return
 	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n%s", name, usage)
+		os.Exit(2)
+	}
+
+	cmd(args)
 }
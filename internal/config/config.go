@@ -1,8 +1,14 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"io"
+	"io/fs"
 	"log"
+	"net/url"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -10,22 +16,46 @@ import (
 
 	"github.com/BurntSushi/toml"
 
+	"github.com/ByteBakersCo/babilema/internal/theme"
 	"github.com/ByteBakersCo/babilema/internal/utils"
 )
 
 const DefaultConfigFileName string = ".babilema.toml"
 
 type Config struct {
-	WebsiteURL             string `toml:"website_url"`
-	BlogTitle              string `toml:"blog_title"`
-	BlogPostIssuePrefix    string `toml:"blog_post_issue_prefix"`
-	TemplatePostFilePath   string `toml:"template_post_file_path"`
-	TemplateHeaderFilePath string `toml:"template_header_file_path"`
-	TemplateFooterFilePath string `toml:"template_footer_file_path"`
-	TemplateIndexFilePath  string `toml:"template_index_file_path"`
-	CSSDir                 string `toml:"css_dir"`
-	OutputDir              string `toml:"output_dir"`
-	TempDir                string `toml:"temp_dir"`
+	WebsiteURL             string   `toml:"website_url"`
+	BlogTitle              string   `toml:"blog_title"`
+	BlogPostIssuePrefix    string   `toml:"blog_post_issue_prefix"`
+	TemplatePostFilePath   string   `toml:"template_post_file_path"`
+	TemplateHeaderFilePath string   `toml:"template_header_file_path"`
+	TemplateFooterFilePath string   `toml:"template_footer_file_path"`
+	TemplateIndexFilePath  string   `toml:"template_index_file_path"`
+	CSSDir                 string   `toml:"css_dir"`
+	CSSBundle              bool     `toml:"css_bundle"`
+	CSSOrder               []string `toml:"css_order"`
+	JSDir                  string   `toml:"js_dir"`
+	JSDefer                bool     `toml:"js_defer"`
+	JSModule               bool     `toml:"js_module"`
+	OutputDir              string   `toml:"output_dir"`
+	TempDir                string   `toml:"temp_dir"`
+	ArchiveDir             string   `toml:"archive_dir"`
+	PostsDir               string   `toml:"posts_dir"`
+	Theme                  string   `toml:"theme"`
+	StaticDir              string   `toml:"static_dir"`
+	MinifyHTML             bool     `toml:"minify_html"`
+	MarkdownExtensions     []string `toml:"markdown_extensions"`
+	MarkdownHTMLFlags      []string `toml:"markdown_html_flags"`
+	SelfHostImages         bool     `toml:"self_host_images"`
+	ResponsiveImages       bool     `toml:"responsive_images"`
+	ImageWidths            []int    `toml:"image_widths"`
+	ImageSizes             string   `toml:"image_sizes"`
+	SyntaxHighlighting     bool     `toml:"syntax_highlighting"`
+	HighlightStyle         string   `toml:"highlight_style"`
+	Math                   bool     `toml:"math"`
+	MermaidScriptURL       string   `toml:"mermaid_script_url"`
+	AllowUnsafeHTML        bool     `toml:"allow_unsafe_html"`
+	AllowedHTMLTags        []string `toml:"allowed_html_tags"`
+	AllowedHTMLAttributes  []string `toml:"allowed_html_attributes"`
 }
 
 func DefaultConfigPath() (string, error) {
@@ -47,8 +77,10 @@ func defaultConfig(root string) Config {
 		TemplateFooterFilePath: filepath.Join(root, "templates", "footer.html"),
 		TemplateIndexFilePath:  filepath.Join(root, "templates", "index.html"),
 		CSSDir:                 filepath.Join(root, "templates", "css"),
+		JSDir:                  filepath.Join(root, "templates", "js"),
 		OutputDir:              root,
 		TempDir:                filepath.Join(root, "tmp"),
+		PostsDir:               filepath.Join(root, "posts"),
 	}
 }
 
@@ -104,7 +136,9 @@ func fixPaths(cfg Config) (Config, error) {
 	cfg.TemplateFooterFilePath, _ = trimPath(cfg.TemplateFooterFilePath)
 	cfg.TemplateIndexFilePath, _ = trimPath(cfg.TemplateIndexFilePath)
 	cfg.CSSDir, _ = trimPath(cfg.CSSDir)
+	cfg.JSDir, _ = trimPath(cfg.JSDir)
 	cfg.OutputDir, _ = trimPath(cfg.OutputDir)
+	cfg.PostsDir, _ = trimPath(cfg.PostsDir)
 	cfg.TemplatePostFilePath = filepath.Join(
 		rootDir,
 		cfg.TemplatePostFilePath,
@@ -122,7 +156,24 @@ func fixPaths(cfg Config) (Config, error) {
 		cfg.TemplateIndexFilePath,
 	)
 	cfg.CSSDir = filepath.Join(rootDir, cfg.CSSDir)
+	cfg.JSDir = filepath.Join(rootDir, cfg.JSDir)
 	cfg.OutputDir = filepath.Join(rootDir, cfg.OutputDir)
+	cfg.PostsDir = filepath.Join(rootDir, cfg.PostsDir)
+
+	if cfg.ArchiveDir != "" {
+		cfg.ArchiveDir, _ = trimPath(cfg.ArchiveDir)
+		cfg.ArchiveDir = filepath.Join(rootDir, cfg.ArchiveDir)
+	}
+
+	if cfg.Theme != "" {
+		cfg.Theme, _ = trimPath(cfg.Theme)
+		cfg.Theme = filepath.Join(rootDir, cfg.Theme)
+	}
+
+	if cfg.StaticDir != "" {
+		cfg.StaticDir, _ = trimPath(cfg.StaticDir)
+		cfg.StaticDir = filepath.Join(rootDir, cfg.StaticDir)
+	}
 
 	return cfg, nil
 }
@@ -151,3 +202,219 @@ func LoadConfig(configFilePath string) (Config, error) {
 
 	return cfg, nil
 }
+
+func hashFiles(hash io.Writer, paths ...string) error {
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = io.Copy(hash, file)
+		file.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// walkFiles returns the files in dir ending with ext (every file if ext is
+// empty). A missing dir has no files.
+func walkFiles(dir string, ext string) ([]string, error) {
+	if dir == "" {
+		return nil, nil
+	}
+
+	var files []string
+	err := filepath.WalkDir(
+		dir,
+		func(path string, entry fs.DirEntry, err error) error {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			if !entry.IsDir() && strings.HasSuffix(path, ext) {
+				files = append(files, path)
+			}
+
+			return nil
+		},
+	)
+
+	return files, err
+}
+
+// hashDefaultTheme hashes the files of the default theme, which change with
+// Babilema upgrades.
+func hashDefaultTheme(hash io.Writer) error {
+	return fs.WalkDir(
+		theme.Default,
+		".",
+		func(path string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() {
+				return err
+			}
+
+			content, err := fs.ReadFile(theme.Default, path)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(hash, "%s %d\n", path, len(content))
+			_, err = hash.Write(content)
+
+			return err
+		},
+	)
+}
+
+// portableConfig returns the settings of cfg that change how pages are
+// rendered, with paths relative to the root of the repository so that they
+// do not depend on where it is cloned.
+func portableConfig(cfg Config) (Config, error) {
+	rootDir, err := utils.RootDir()
+	if err != nil {
+		return Config{}, err
+	}
+
+	paths := []*string{
+		&cfg.TemplatePostFilePath,
+		&cfg.TemplateHeaderFilePath,
+		&cfg.TemplateFooterFilePath,
+		&cfg.TemplateIndexFilePath,
+		&cfg.CSSDir,
+		&cfg.JSDir,
+		&cfg.OutputDir,
+		&cfg.Theme,
+		&cfg.StaticDir,
+	}
+	for _, path := range paths {
+		if *path == "" || !filepath.IsAbs(*path) {
+			continue
+		}
+
+		relativePath, err := filepath.Rel(rootDir, *path)
+		if err != nil {
+			return Config{}, err
+		}
+
+		*path = filepath.ToSlash(relativePath)
+	}
+
+	// Where posts are built from or archived to does not change them
+	cfg.TempDir = ""
+	cfg.ArchiveDir = ""
+	cfg.PostsDir = ""
+
+	return cfg, nil
+}
+
+func fingerprint(cfg Config, paths ...string) (string, error) {
+	portable, err := portableConfig(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%+v", portable)
+
+	err = hashDefaultTheme(hash)
+	if err != nil {
+		return "", err
+	}
+
+	err = hashFiles(hash, paths...)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// assetFiles returns the CSS, JS and theme files linked from every page.
+func assetFiles(cfg Config) ([]string, error) {
+	css, err := walkFiles(cfg.CSSDir, ".css")
+	if err != nil {
+		return nil, err
+	}
+
+	js, err := walkFiles(cfg.JSDir, ".js")
+	if err != nil {
+		return nil, err
+	}
+
+	themeFiles, err := walkFiles(cfg.Theme, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(append(css, js...), themeFiles...), nil
+}
+
+// PostFingerprint returns a hash of the config, templates, CSS and JS files
+// (including the default theme's ones) used to render a blog post. Any change
+// to them means every post is outdated.
+func PostFingerprint(cfg Config) (string, error) {
+	paths := []string{
+		cfg.TemplatePostFilePath,
+		cfg.TemplateHeaderFilePath,
+		cfg.TemplateFooterFilePath,
+	}
+
+	assets, err := assetFiles(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	return fingerprint(cfg, append(paths, assets...)...)
+}
+
+// IndexFingerprint returns a hash of the config, templates, CSS and JS files
+// used to render the blog index page.
+func IndexFingerprint(cfg Config) (string, error) {
+	paths := []string{
+		cfg.TemplateIndexFilePath,
+		cfg.TemplateHeaderFilePath,
+		cfg.TemplateFooterFilePath,
+	}
+
+	assets, err := assetFiles(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	return fingerprint(cfg, append(paths, assets...)...)
+}
+
+// Validate returns the problems found in the config. Missing templates are
+// not a problem since the default theme is used in their place.
+func Validate(cfg Config) []error {
+	var problems []error
+
+	websiteURL, err := url.Parse(cfg.WebsiteURL)
+	if err != nil {
+		problems = append(problems, fmt.Errorf("website_url: %w", err))
+	} else if websiteURL.Scheme == "" || websiteURL.Host == "" {
+		problems = append(problems, fmt.Errorf(
+			"website_url: %q is not an absolute URL",
+			cfg.WebsiteURL,
+		))
+	}
+
+	if cfg.BlogPostIssuePrefix == "" {
+		problems = append(
+			problems,
+			errors.New("blog_post_issue_prefix: cannot be empty"),
+		)
+	}
+
+	return problems
+}
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/ByteBakersCo/babilema/internal/utils"
@@ -18,8 +20,10 @@ func TestLoadConfig(t *testing.T) {
 		TemplateFooterFilePath: filepath.Join(root, "templates", "footer.html"),
 		TemplateIndexFilePath:  filepath.Join(root, "templates", "index.html"),
 		CSSDir:                 filepath.Join(root, "templates", "css"),
+		JSDir:                  filepath.Join(root, "templates", "js"),
 		OutputDir:              root,
 		TempDir:                filepath.Join(root, "tmp"),
+		PostsDir:               filepath.Join(root, "posts"),
 	}
 
 	configPath := filepath.Join(root, DefaultConfigFileName)
@@ -29,7 +33,7 @@ func TestLoadConfig(t *testing.T) {
 		t.Error(err)
 	}
 
-	if expected != actual {
+	if !reflect.DeepEqual(expected, actual) {
 		t.Error(
 			utils.FormatStruct(expected, "Expected output to be"),
 			utils.FormatStruct(actual, "\ngot"),
@@ -45,10 +49,94 @@ func TestLoadConfig(t *testing.T) {
 		t.Error(err)
 	}
 
-	if expected != actual {
+	if !reflect.DeepEqual(expected, actual) {
 		t.Error(
 			utils.FormatStruct(expected, "Expected output to be"),
 			utils.FormatStruct(actual, "\ngot"),
 		)
 	}
 }
+
+func TestPostFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	cfg := Config{
+		TemplatePostFilePath: filepath.Join(dir, "post.html"),
+		CSSDir:               filepath.Join(dir, "css"),
+	}
+
+	err := os.WriteFile(cfg.TemplatePostFilePath, []byte("foo"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before, err := PostFingerprint(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	again, _ := PostFingerprint(cfg)
+	if before != again {
+		t.Errorf(
+			"Expected fingerprint to be stable, got %s and %s",
+			before,
+			again,
+		)
+	}
+
+	err = os.MkdirAll(cfg.CSSDir, os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cssFile := filepath.Join(cfg.CSSDir, "foo.css")
+	err = os.WriteFile(cssFile, []byte("a{}"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := PostFingerprint(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if before == after {
+		t.Error("Expected fingerprint to change when a CSS file is added")
+	}
+}
+
+func TestPortableConfig(t *testing.T) {
+	rootDir, err := utils.RootDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := defaultConfig(rootDir)
+	cfg.Theme = filepath.Join(rootDir, "themes", "foo")
+
+	portable, err := portableConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if portable.TemplatePostFilePath != "templates/post.html" ||
+		portable.OutputDir != "." || portable.Theme != "themes/foo" ||
+		portable.TempDir != "" {
+		t.Errorf("Expected paths relative to the root, got %+v", portable)
+	}
+
+	// Where posts are built does not change them
+	before, err := PostFingerprint(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.TempDir = t.TempDir()
+	after, err := PostFingerprint(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if before != after {
+		t.Error("Expected fingerprint not to depend on temp_dir")
+	}
+}
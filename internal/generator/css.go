@@ -0,0 +1,229 @@
+package generator
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ByteBakersCo/babilema/internal/config"
+	"github.com/ByteBakersCo/babilema/internal/highlight"
+	"github.com/ByteBakersCo/babilema/internal/history"
+	"github.com/ByteBakersCo/babilema/internal/theme"
+)
+
+// orderFiles sorts files (slash separated paths) following the patterns of
+// order: files matching the first pattern come first, and so on. Files that
+// match no pattern come last, in lexical order.
+func orderFiles(files []string, order []string) []string {
+	sorted := append([]string{}, files...)
+	sort.Strings(sorted)
+
+	rank := func(file string) int {
+		for i, pattern := range order {
+			if ok, _ := path.Match(pattern, file); ok {
+				return i
+			}
+		}
+
+		return len(order)
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return rank(sorted[i]) < rank(sorted[j])
+	})
+
+	return sorted
+}
+
+// readStylesheets returns the content of the stylesheets of cfg.CSSDir (or
+// of the theme if it does not exist) in the order set by cfg.CSSOrder.
+func readStylesheets(cfg config.Config) ([][]byte, error) {
+	fsys, stylesheets, err := themeAssets(
+		cfg.CSSDir,
+		theme.CSSDir,
+		".css",
+		cfg,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if fsys != nil {
+		for i, stylesheet := range stylesheets {
+			stylesheets[i] = strings.TrimPrefix(stylesheet, theme.CSSDir+"/")
+		}
+
+		fsys, err = fs.Sub(fsys, theme.CSSDir)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		fsys = os.DirFS(cfg.CSSDir)
+		err = fs.WalkDir(
+			fsys,
+			".",
+			func(path string, entry fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+
+				if entry.IsDir() || !strings.HasSuffix(path, ".css") {
+					return nil
+				}
+
+				// Skip previous bundles if they are published to cfg.CSSDir
+				bundle, _ := filepath.Match("bundle.*.css", entry.Name())
+				if !bundle {
+					stylesheets = append(stylesheets, path)
+				}
+
+				return nil
+			},
+		)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var contents [][]byte
+	for _, stylesheet := range orderFiles(stylesheets, cfg.CSSOrder) {
+		content, err := fs.ReadFile(fsys, stylesheet)
+		if err != nil {
+			return nil, err
+		}
+
+		contents = append(contents, content)
+	}
+
+	return contents, nil
+}
+
+func isCSSSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// minifyCSS removes the comments and the whitespace that is not needed.
+// Strings are left untouched, and so are the spaces before a colon, which
+// matter in selectors (e.g. "a :hover").
+func minifyCSS(css []byte) []byte {
+	var out bytes.Buffer
+	space := false
+
+	last := func() byte {
+		if out.Len() == 0 {
+			return '{'
+		}
+
+		return out.Bytes()[out.Len()-1]
+	}
+
+	for i := 0; i < len(css); i++ {
+		c := css[i]
+		switch {
+		case c == '/' && i+1 < len(css) && css[i+1] == '*':
+			end := bytes.Index(css[i+2:], []byte("*/"))
+			if end < 0 {
+				i = len(css)
+			} else {
+				i += end + 3
+			}
+
+			space = true
+
+		case isCSSSpace(c):
+			space = true
+
+		default:
+			if space && !strings.ContainsRune("{};,>:", rune(last())) &&
+				!strings.ContainsRune("{};,>", rune(c)) {
+				out.WriteByte(' ')
+			}
+
+			space = false
+
+			if c == '}' && last() == ';' {
+				out.Truncate(out.Len() - 1)
+			}
+
+			if c != '"' && c != '\'' {
+				out.WriteByte(c)
+				continue
+			}
+
+			// Copy the string as is, up to the closing quote
+			start := i
+			for i++; i < len(css) && css[i] != c; i++ {
+				if css[i] == '\\' {
+					i++
+				}
+			}
+
+			out.Write(css[start:min(i+1, len(css))])
+		}
+	}
+
+	return out.Bytes()
+}
+
+// bundleCSS returns the path (relative to cfg.OutputDir) and content of the
+// minified concatenation of the stylesheets. The file name contains the hash
+// of its content so that it can be cached forever.
+// There is no bundle if cfg.CSSBundle is not set or there is nothing to
+// bundle.
+func bundleCSS(cfg config.Config) (string, []byte, error) {
+	if !cfg.CSSBundle || cfg.CSSDir == "" {
+		return "", nil, nil
+	}
+
+	stylesheets, err := readStylesheets(cfg)
+	if err != nil {
+		return "", nil, err
+	}
+
+	_, highlightCSS, err := highlightStylesheet(cfg)
+	if err != nil {
+		return "", nil, err
+	}
+
+	stylesheets = append(stylesheets, highlightCSS)
+	bundle := minifyCSS(bytes.Join(stylesheets, []byte("\n")))
+	if len(bundle) == 0 {
+		return "", nil, nil
+	}
+
+	filename := filepath.Join(
+		theme.CSSDir,
+		"bundle."+history.Hash(bundle)[:12]+".css",
+	)
+
+	return filename, bundle, nil
+}
+
+// highlightStylesheet returns the path (relative to cfg.OutputDir) and
+// content of the stylesheet coloring highlighted code, if
+// cfg.SyntaxHighlighting is set.
+func highlightStylesheet(cfg config.Config) (string, []byte, error) {
+	if !cfg.SyntaxHighlighting {
+		return "", nil, nil
+	}
+
+	style := cfg.HighlightStyle
+	if style == "" {
+		style = highlight.DefaultStyle
+	}
+
+	css, err := highlight.CSS(style)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return filepath.Join(theme.CSSDir, "highlight.css"), css, nil
+}
@@ -0,0 +1,147 @@
+package generator
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ByteBakersCo/babilema/internal/config"
+)
+
+func TestMinifyCSS(t *testing.T) {
+	css := `/* Comment */
+body {
+    margin : 0;
+    font-family: "Open  Sans", sans-serif;
+}
+
+a :hover,
+ul > li {
+    width: calc(100% - 2em);
+    content: 'a } b';
+}
+`
+
+	expected := `body{margin :0;font-family:"Open  Sans",sans-serif}` +
+		`a :hover,ul>li{width:calc(100% - 2em);content:'a } b'}`
+
+	actual := string(minifyCSS([]byte(css)))
+	if actual != expected {
+		t.Errorf("Expected %q, got %q", expected, actual)
+	}
+}
+
+func TestOrderFiles(t *testing.T) {
+	files := []string{
+		"z.css",
+		"base/b.css",
+		"reset.css",
+		"a.css",
+		"base/a.css",
+	}
+	order := []string{"reset.css", "base/*.css"}
+
+	expected := []string{
+		"reset.css",
+		"base/a.css",
+		"base/b.css",
+		"a.css",
+		"z.css",
+	}
+	actual := orderFiles(files, order)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v, got %v", expected, actual)
+	}
+}
+
+func TestBundleCSS(t *testing.T) {
+	root := t.TempDir()
+	cfg := config.Config{
+		CSSDir:     filepath.Join(root, "css"),
+		CSSBundle:  true,
+		CSSOrder:   []string{"reset.css"},
+		OutputDir:  root,
+		WebsiteURL: "http://localhost:8080",
+	}
+
+	writeFiles(t, cfg.CSSDir, map[string]string{
+		"main.css":  "a { color: red; }",
+		"reset.css": "* { margin: 0; }",
+	})
+
+	filename, content, err := bundleCSS(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "*{margin:0}a{color:red}"
+	if string(content) != expected {
+		t.Errorf("Expected bundle to be %q, got %q", expected, content)
+	}
+
+	if !strings.HasPrefix(filename, filepath.Join("css", "bundle.")) {
+		t.Errorf("Expected a bundle in css/, got %s", filename)
+	}
+
+	links, err := extractCSSLinks(cfg.CSSDir, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	link, _ := fileURL(filepath.Join(root, filename), cfg)
+	if !reflect.DeepEqual(links, []string{link}) {
+		t.Errorf("Expected only the bundle to be linked, got %v", links)
+	}
+
+	// The bundle changes with the stylesheets
+	writeFiles(t, cfg.CSSDir, map[string]string{"main.css": "a{color:blue}"})
+	changed, _, err := bundleCSS(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if changed == filename {
+		t.Errorf("Expected the bundle name to change, got %s twice", filename)
+	}
+}
+
+func TestHighlightStylesheet(t *testing.T) {
+	root := t.TempDir()
+	cfg := config.Config{
+		CSSDir:             filepath.Join(root, "css"),
+		OutputDir:          root,
+		WebsiteURL:         "http://localhost:8080",
+		SyntaxHighlighting: true,
+	}
+
+	writeFiles(t, cfg.CSSDir, map[string]string{"main.css": "a{}"})
+
+	links, err := extractCSSLinks(cfg.CSSDir, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	main, _ := fileURL(filepath.Join(cfg.CSSDir, "main.css"), cfg)
+	highlight, _ := fileURL(filepath.Join(root, "css", "highlight.css"), cfg)
+	expected := []string{main, highlight}
+	if !reflect.DeepEqual(links, expected) {
+		t.Errorf("Expected %v, got %v", expected, links)
+	}
+
+	assets, err := generatedAssets(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(assets) != 1 ||
+		assets[0].filename != filepath.Join("css", "highlight.css") {
+		t.Errorf("Expected the highlight stylesheet asset, got %v", assets)
+	}
+
+	cfg.HighlightStyle = "unknown"
+	_, err = extractCSSLinks(cfg.CSSDir, cfg)
+	if err == nil {
+		t.Error("Expected an error for an unknown highlight style")
+	}
+}
@@ -2,8 +2,11 @@ package generator
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"html/template"
 	"io"
+	"io/fs"
 	"log"
 	"net/url"
 	"os"
@@ -14,7 +17,9 @@ import (
 	"golang.org/x/net/html"
 
 	"github.com/ByteBakersCo/babilema/internal/config"
+	"github.com/ByteBakersCo/babilema/internal/history"
 	"github.com/ByteBakersCo/babilema/internal/parser"
+	"github.com/ByteBakersCo/babilema/internal/theme"
 	"github.com/ByteBakersCo/babilema/internal/utils"
 )
 
@@ -25,43 +30,77 @@ type templateData struct {
 	Header   template.HTML
 	Footer   template.HTML
 	CSSLinks []string
+	JSLinks  []scriptLink
+
+	// The table of contents of the post, if its front matter has toc = true
+	TableOfContentsHTML template.HTML
+}
+
+// scriptLink is a script included by the templates, e.g.
+// <script src="{{.URL}}"{{if .Defer}} defer{{end}}></script>
+type scriptLink struct {
+	URL    string
+	Defer  bool
+	Module bool
 }
 
 type article struct {
 	Image         string
 	Title         string
 	Author        string
-	Preview       template.HTML
+	Preview       string
 	DatePublished time.Time
 	URL           string
+
+	// Set when cfg.ResponsiveImages is set and Image is a local image
+	ResponsiveImage *responsiveImage
 }
 
-func moveGeneratedFilesToOutputDir(cfg config.Config) error {
-	files, err := os.ReadDir(cfg.TempDir)
-	if err != nil {
-		return err
+// themes returns the themes that provide what the site does not, by priority.
+func themes(cfg config.Config) []fs.FS {
+	var themes []fs.FS
+	if cfg.Theme != "" {
+		themes = append(themes, os.DirFS(cfg.Theme))
 	}
 
-	for _, file := range files {
-		src := filepath.Join(cfg.TempDir, file.Name())
-		dest := filepath.Join(cfg.OutputDir, file.Name())
+	return append(themes, theme.Default)
+}
 
-		err = os.Rename(src, dest)
-		if err != nil {
-			return err
-		}
+// parseTemplate parses the template file at path. If it does not exist, the
+// template called name is looked up in cfg.Theme, then in the default theme.
+func parseTemplate(
+	path string,
+	name string,
+	cfg config.Config,
+) (*template.Template, error) {
+	_, err := os.Stat(path)
+	if err == nil || !errors.Is(err, os.ErrNotExist) {
+		return template.ParseFiles(path)
 	}
 
-	err = os.RemoveAll(cfg.TempDir)
-	if err != nil {
-		return err
+	available := themes(cfg)
+	for i, fsys := range available {
+		if _, err := fs.Stat(fsys, name); err != nil {
+			continue
+		}
+
+		if i == len(available)-1 {
+			log.Printf("%s not found, using the default %s.\n", path, name)
+		}
+
+		return template.ParseFS(fsys, name)
 	}
 
-	return nil
+	return nil, fmt.Errorf("template %s not found", name)
 }
 
-func extractHTML(filePath string, data interface{}) (template.HTML, error) {
-	tmpl, err := template.ParseFiles(filePath)
+func extractHTML(
+	filePath string,
+	defaultName string,
+	cfg config.Config,
+	data interface{},
+) (template.HTML, error) {
+	tmpl, err := parseTemplate(filePath, defaultName, cfg)
 	if err != nil {
 		return "", err
 	}
@@ -100,36 +139,102 @@ func extractPlainText(content template.HTML) string {
 	return result
 }
 
-func extractCSSLinks(cssDir string, cfg config.Config) ([]string, error) {
-	if cssDir == "" {
+// fileURL returns the URL path of a file of the website.
+func fileURL(path string, cfg config.Config) (string, error) {
+	relativeFilePath, err := utils.RelativeFilePath(path)
+	if err != nil {
+		return "", err
+	}
+
+	websiteURL, err := url.Parse(cfg.WebsiteURL)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(websiteURL.Path, relativeFilePath), nil
+}
+
+// themeAssets returns the theme providing the files ending with ext when
+// siteDir does not exist, along with their paths within the theme (in its
+// themeDir directory). They are published to cfg.OutputDir.
+func themeAssets(
+	siteDir string,
+	themeDir string,
+	ext string,
+	cfg config.Config,
+) (fs.FS, []string, error) {
+	if siteDir == "" {
+		return nil, nil, nil
+	}
+
+	_, err := os.Stat(siteDir)
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, nil
+	}
+
+	for _, fsys := range themes(cfg) {
+		assets, err := fs.Glob(fsys, themeDir+"/*"+ext)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(assets) > 0 {
+			return fsys, assets, nil
+		}
+	}
+
+	return nil, nil, nil
+}
+
+// extractLinks returns the URLs of the files ending with ext in siteDir, or
+// in the theme's themeDir if siteDir does not exist.
+func extractLinks(
+	siteDir string,
+	themeDir string,
+	ext string,
+	cfg config.Config,
+) ([]string, error) {
+	if siteDir == "" {
 		return nil, nil
 	}
 
-	var cssLinks []string
-	err := filepath.Walk(
-		cssDir,
+	_, assets, err := themeAssets(siteDir, themeDir, ext, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if assets != nil {
+		var links []string
+		for _, asset := range assets {
+			link, err := fileURL(
+				filepath.Join(cfg.OutputDir, filepath.FromSlash(asset)),
+				cfg,
+			)
+			if err != nil {
+				return nil, err
+			}
+
+			links = append(links, link)
+		}
+
+		return links, nil
+	}
+
+	var links []string
+	err = filepath.Walk(
+		siteDir,
 		func(path string, info os.FileInfo, err error) error {
 			if err != nil {
 				return err
 			}
 
-			if !info.IsDir() && strings.HasSuffix(path, ".css") {
-				relativeFilePath, err := utils.RelativeFilePath(path)
+			if !info.IsDir() && strings.HasSuffix(path, ext) {
+				link, err := fileURL(path, cfg)
 				if err != nil {
 					return err
 				}
 
-				websiteURL, err := url.Parse(cfg.WebsiteURL)
-				if err != nil {
-					return err
-				}
-
-				relativeFilePath = filepath.Join(
-					websiteURL.Path,
-					relativeFilePath,
-				)
-
-				cssLinks = append(cssLinks, relativeFilePath)
+				links = append(links, link)
 			}
 
 			return nil
@@ -139,18 +244,230 @@ func extractCSSLinks(cssDir string, cfg config.Config) ([]string, error) {
 		return nil, err
 	}
 
-	return cssLinks, nil
+	return links, nil
+}
+
+func extractCSSLinks(cssDir string, cfg config.Config) ([]string, error) {
+	if !cfg.CSSBundle {
+		links, err := extractLinks(cssDir, theme.CSSDir, ".css", cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		stylesheet, _, err := highlightStylesheet(cfg)
+		if err != nil || stylesheet == "" {
+			return links, err
+		}
+
+		link, err := fileURL(filepath.Join(cfg.OutputDir, stylesheet), cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		return append(links, link), nil
+	}
+
+	bundle, _, err := bundleCSS(cfg)
+	if err != nil || bundle == "" {
+		return nil, err
+	}
+
+	link, err := fileURL(filepath.Join(cfg.OutputDir, bundle), cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{link}, nil
+}
+
+func extractJSLinks(jsDir string, cfg config.Config) ([]scriptLink, error) {
+	links, err := extractLinks(jsDir, theme.JSDir, ".js", cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var scriptLinks []scriptLink
+	for _, link := range links {
+		scriptLinks = append(scriptLinks, scriptLink{
+			URL:    link,
+			Defer:  cfg.JSDefer,
+			Module: cfg.JSModule,
+		})
+	}
+
+	return scriptLinks, nil
+}
+
+// Used when cfg.MermaidScriptURL is not set
+const defaultMermaidScriptURL string = "https://cdn.jsdelivr.net/npm/" +
+	"mermaid@11/dist/mermaid.min.js"
+
+// postJSLinks returns the scripts of the post: jsLinks, and the Mermaid
+// script if the post has diagrams.
+func postJSLinks(
+	jsLinks []scriptLink,
+	issue parser.ParsedIssue,
+	cfg config.Config,
+) []scriptLink {
+	if !issue.HasMermaid {
+		return jsLinks
+	}
+
+	mermaidURL := cfg.MermaidScriptURL
+	if mermaidURL == "" {
+		mermaidURL = defaultMermaidScriptURL
+	}
+
+	// Mermaid draws the diagrams once the page is loaded
+	return append(
+		append([]scriptLink{}, jsLinks...),
+		scriptLink{URL: mermaidURL, Defer: true},
+	)
+}
+
+// asset is a file published along with the pages, e.g. a theme stylesheet.
+type asset struct {
+	// Relative to cfg.OutputDir
+	filename string
+	content  func() ([]byte, error)
+}
+
+// generatedAssets returns the CSS bundle (if cfg.CSSBundle is set), the
+// highlight stylesheet (if cfg.SyntaxHighlighting is set) and the theme's
+// stylesheets and scripts used in place of cfg.CSSDir and cfg.JSDir.
+func generatedAssets(cfg config.Config) ([]asset, error) {
+	var assets []asset
+
+	bundle, content, err := bundleCSS(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if bundle != "" {
+		assets = append(assets, asset{
+			filename: bundle,
+			content:  func() ([]byte, error) { return content, nil },
+		})
+	}
+
+	// The highlight stylesheet is part of the bundle too
+	stylesheet, highlightCSS, err := highlightStylesheet(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if stylesheet != "" && !cfg.CSSBundle {
+		assets = append(assets, asset{
+			filename: stylesheet,
+			content:  func() ([]byte, error) { return highlightCSS, nil },
+		})
+	}
+
+	type assetDir struct {
+		siteDir  string
+		themeDir string
+		ext      string
+	}
+
+	dirs := []assetDir{{cfg.JSDir, theme.JSDir, ".js"}}
+
+	// The theme's stylesheets are part of the bundle
+	if !cfg.CSSBundle {
+		dirs = append(dirs, assetDir{cfg.CSSDir, theme.CSSDir, ".css"})
+	}
+
+	for _, dir := range dirs {
+		themeFS, files, err := themeAssets(
+			dir.siteDir,
+			dir.themeDir,
+			dir.ext,
+			cfg,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			assets = append(assets, asset{
+				filename: filepath.FromSlash(file),
+				content: func() ([]byte, error) {
+					return fs.ReadFile(themeFS, file)
+				},
+			})
+		}
+	}
+
+	return assets, nil
+}
+
+// writeAssets writes the generated assets and returns their names.
+func writeAssets(cfg config.Config, write pageWriter) ([]string, error) {
+	assets, err := generatedAssets(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var written []string
+	for _, asset := range assets {
+		err = write(asset.filename, func(writer io.Writer) error {
+			content, err := asset.content()
+			if err != nil {
+				return err
+			}
+
+			_, err = writer.Write(content)
+
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		written = append(written, asset.filename)
+	}
+
+	return written, nil
+}
+
+// pageWriter writes the page named filename, rendered by render.
+type pageWriter func(filename string, render func(io.Writer) error) error
+
+// fileWriter writes pages to dir.
+func fileWriter(dir string) pageWriter {
+	return func(filename string, render func(io.Writer) error) error {
+		path := filepath.Join(dir, filename)
+		err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+		if err != nil {
+			return err
+		}
+
+		outputFile, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+
+		err = render(outputFile)
+		closeErr := outputFile.Close()
+		if err != nil {
+			return err
+		}
+
+		return closeErr
+	}
+}
+
+// streamWriter writes every page to the same writer.
+func streamWriter(writer io.Writer) pageWriter {
+	return func(_ string, render func(io.Writer) error) error {
+		return render(writer)
+	}
 }
 
 func generateBlogIndexPage(
 	articles []article,
 	cfg config.Config,
-	testOutputWriter io.Writer, // for testing purposes
+	write pageWriter,
 ) error {
-	if len(articles) == 0 {
-		return nil
-	}
-
 	websiteURL, err := url.Parse(cfg.WebsiteURL)
 	if err != nil {
 		return err
@@ -161,139 +478,500 @@ func generateBlogIndexPage(
 	}
 
 	data := struct {
-		Header   template.HTML
-		Footer   template.HTML
-		Articles []article
+		Header    template.HTML
+		Footer    template.HTML
+		BlogTitle string
+		CSSLinks  []string
+		JSLinks   []scriptLink
+		Articles  []article
 	}{
-		Articles: articles,
+		BlogTitle: cfg.BlogTitle,
+		Articles:  articles,
 	}
 
-	indexTemplate, err := template.ParseFiles(cfg.TemplateIndexFilePath)
+	data.CSSLinks, err = extractCSSLinks(cfg.CSSDir, cfg)
 	if err != nil {
 		return err
 	}
 
-	// TODO: add possibility to inject custom data to header and footer
-	data.Header, err = extractHTML(cfg.TemplateHeaderFilePath, nil)
+	data.JSLinks, err = extractJSLinks(cfg.JSDir, cfg)
 	if err != nil {
 		return err
 	}
 
-	data.Footer, err = extractHTML(cfg.TemplateFooterFilePath, nil)
+	indexTemplate, err := parseTemplate(
+		cfg.TemplateIndexFilePath,
+		theme.IndexTemplate,
+		cfg,
+	)
 	if err != nil {
 		return err
 	}
 
-	writer := testOutputWriter
-	if writer == nil {
-		filename := filepath.Base(cfg.TemplateIndexFilePath)
-		path := filepath.Join(cfg.TempDir, filename)
-
-		outputFile, error := os.Create(path)
-		if error != nil {
-			return error
-		}
-
-		defer outputFile.Close()
-
-		writer = outputFile
+	// TODO: add possibility to inject custom data to header and footer
+	data.Header, err = extractHTML(
+		cfg.TemplateHeaderFilePath,
+		theme.HeaderTemplate,
+		cfg,
+		nil,
+	)
+	if err != nil {
+		return err
 	}
 
-	log.Println("Generating blog index page...")
-	err = indexTemplate.Execute(writer, data)
+	data.Footer, err = extractHTML(
+		cfg.TemplateFooterFilePath,
+		theme.FooterTemplate,
+		cfg,
+		nil,
+	)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	log.Println("Generating blog index page...")
+	filename := filepath.Base(cfg.TemplateIndexFilePath)
+
+	return write(filename, func(writer io.Writer) error {
+		return indexTemplate.Execute(writer, data)
+	})
 }
 
-func GenerateBlogPosts(
+// generateBlog renders the outdated posts and, unless withoutIndex is set,
+// the blog index page. It returns the names of the generated files.
+func generateBlog(
 	parsedIssues []parser.ParsedIssue,
 	cfg config.Config,
-	testOutputWriter io.Writer, // for testing purposes
-) error {
-	if len(parsedIssues) == 0 {
-		return nil
+	write pageWriter,
+	withoutIndex bool,
+) ([]string, error) {
+	if cfg.MinifyHTML {
+		write = minifyingWriter(write)
 	}
 
-	postTemplate, err := template.ParseFiles(cfg.TemplatePostFilePath)
+	postTemplate, err := parseTemplate(
+		cfg.TemplatePostFilePath,
+		theme.PostTemplate,
+		cfg,
+	)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	data := templateData{}
 	data.CSSLinks, err = extractCSSLinks(cfg.CSSDir, cfg)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	jsLinks, err := extractJSLinks(cfg.JSDir, cfg)
+	if err != nil {
+		return nil, err
 	}
 
 	// TODO: add possibility to inject custom data to header and footer
-	data.Header, err = extractHTML(cfg.TemplateHeaderFilePath, nil)
+	data.Header, err = extractHTML(
+		cfg.TemplateHeaderFilePath,
+		theme.HeaderTemplate,
+		cfg,
+		nil,
+	)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	data.Footer, err = extractHTML(cfg.TemplateFooterFilePath, nil)
+	data.Footer, err = extractHTML(
+		cfg.TemplateFooterFilePath,
+		theme.FooterTemplate,
+		cfg,
+		nil,
+	)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	var articles []article
+	articles := []article{}
+	var generated []string
 	for _, issue := range parsedIssues {
 		data.ParsedIssue = issue
-		writer := testOutputWriter
+		data.TableOfContentsHTML = renderTOC(issue.TableOfContents)
+		data.JSLinks = postJSLinks(jsLinks, issue, cfg)
 		filename := issue.Metadata.Slug + ".html"
-		path := filepath.Join(cfg.TempDir, filename)
-
-		if writer == nil {
-			outputFile, error := os.Create(path)
-			if error != nil {
-				return error
-			}
-			defer outputFile.Close()
-
-			writer = outputFile
 
+		if !withoutIndex {
 			var articleURL string
 			articleURL, err = utils.RelativeFilePath(
 				filepath.Join(cfg.OutputDir, filename),
 			)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
 			articles = append(articles, article{
-				Image:         data.Metadata.Image,
-				Title:         data.Metadata.Title,
-				Author:        data.Metadata.Author,
-				Preview:       template.HTML(extractPlainText(data.Content)),
-				DatePublished: data.Metadata.DatePublished,
-				URL:           articleURL,
+				Image:           data.Metadata.Image,
+				ResponsiveImage: previewImage(issue, cfg),
+				Title:           data.Metadata.Title,
+				Author:          data.Metadata.Author,
+				Preview:         extractPlainText(data.Content),
+				DatePublished:   data.Metadata.DatePublished,
+				URL:             articleURL,
 			})
 		}
 
+		if issue.IsUpToDate {
+			continue
+		}
+
 		log.Println("Generating blog post:", data.Metadata.Slug)
-		err = postTemplate.Execute(writer, data)
+		err = write(filename, func(writer io.Writer) error {
+			return postTemplate.Execute(writer, data)
+		})
 		if err != nil {
-			return err
+			return nil, err
+		}
+
+		generated = append(generated, filename)
+
+		if withoutIndex {
+			continue
+		}
+
+		for _, asset := range issue.Assets {
+			err = write(asset.Path, func(writer io.Writer) error {
+				_, err := writer.Write(asset.Content)
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			generated = append(generated, asset.Path)
+		}
+	}
+
+	if withoutIndex {
+		return generated, nil
+	}
+
+	err = generateBlogIndexPage(articles, cfg, write)
+	if err != nil {
+		return nil, err
+	}
+
+	generated = append(generated, filepath.Base(cfg.TemplateIndexFilePath))
+
+	assets, err := writeAssets(cfg, write)
+	if err != nil {
+		return nil, err
+	}
+
+	generated = append(generated, assets...)
+
+	return generated, nil
+}
+
+// isUpToDate tells whether nothing needs to be generated nor removed.
+func isUpToDate(
+	parsedIssues []parser.ParsedIssue,
+	postsHistory history.History,
+	next *history.History,
+	cfg config.Config,
+) (bool, error) {
+	var err error
+	next.IndexHash, err = config.IndexFingerprint(cfg)
+	if err != nil {
+		return false, err
+	}
+
+	assets, err := generatedAssets(cfg)
+	if err != nil {
+		return false, err
+	}
+
+	next.Assets = nil
+	for _, asset := range assets {
+		next.Assets = append(next.Assets, asset.filename)
+	}
+
+	for _, issue := range parsedIssues {
+		if !issue.IsUpToDate {
+			return false, nil
+		}
+	}
+
+	return len(postsHistory.Stale(*next)) == 0 &&
+		next.IndexHash == postsHistory.IndexHash, nil
+}
+
+// GenerateBlogPosts generates the outdated posts and the blog index page,
+// then publishes them to cfg.OutputDir. Pages recorded in postsHistory but
+// not in next are removed.
+func GenerateBlogPosts(
+	parsedIssues []parser.ParsedIssue,
+	postsHistory history.History,
+	next history.History,
+	cfg config.Config,
+	testOutputWriter io.Writer, // for testing purposes
+) error {
+	static, err := diffStaticFiles(postsHistory, &next, cfg)
+	if err != nil {
+		return err
+	}
+
+	upToDate, err := isUpToDate(parsedIssues, postsHistory, &next, cfg)
+	if err != nil {
+		return err
+	}
+
+	if upToDate && len(static) == 0 {
+		log.Println("Blog is up to date, nothing to generate.")
+		return nil
+	}
+
+	parsedIssues, err = addImageVariants(parsedIssues, cfg)
+	if err != nil {
+		return err
+	}
+
+	recordOutputFiles(parsedIssues, next)
+
+	if testOutputWriter != nil {
+		_, err = generateBlog(
+			parsedIssues,
+			cfg,
+			streamWriter(testOutputWriter),
+			true,
+		)
+		return err
+	}
+
+	// Files left behind by a failed build must not be published
+	err = os.RemoveAll(cfg.TempDir)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(cfg.TempDir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	generated, err := generateBlog(
+		parsedIssues,
+		cfg,
+		fileWriter(cfg.TempDir),
+		false,
+	)
+	if err != nil {
+		return err
+	}
+
+	// Static files are not verified, they can legitimately be empty
+	err = copyStaticFiles(static, cfg, fileWriter(cfg.TempDir))
+	if err != nil {
+		return err
+	}
+
+	return publish(generated, static, postsHistory.Stale(next), next, cfg)
+}
+
+// allOutdated returns a copy of parsedIssues where no post is up to date.
+func allOutdated(parsedIssues []parser.ParsedIssue) []parser.ParsedIssue {
+	outdated := make([]parser.ParsedIssue, len(parsedIssues))
+	for i, issue := range parsedIssues {
+		issue.IsUpToDate = false
+		outdated[i] = issue
+	}
+
+	return outdated
+}
+
+// Preview renders every post and the blog index page to cfg.TempDir, along
+// with the static files, without publishing them nor touching the history
+// file.
+func Preview(parsedIssues []parser.ParsedIssue, cfg config.Config) error {
+	err := os.RemoveAll(cfg.TempDir)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(cfg.TempDir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	parsedIssues, err = addImageVariants(allOutdated(parsedIssues), cfg)
+	if err != nil {
+		return err
+	}
+
+	_, err = generateBlog(parsedIssues, cfg, fileWriter(cfg.TempDir), false)
+	if err != nil {
+		return err
+	}
+
+	next := history.New()
+	static, err := diffStaticFiles(history.New(), &next, cfg)
+	if err != nil {
+		return err
+	}
+
+	return copyStaticFiles(static, cfg, fileWriter(cfg.TempDir))
+}
+
+// Validate renders every post and the blog index page without writing
+// anything and returns the problems found.
+func Validate(parsedIssues []parser.ParsedIssue, cfg config.Config) []string {
+	var problems []string
+
+	slugs := make(map[string]int)
+	for _, issue := range parsedIssues {
+		slug := issue.Metadata.Slug
+		if number, ok := slugs[slug]; ok {
+			problems = append(problems, fmt.Sprintf(
+				"issues #%d and #%d share the slug %q",
+				number,
+				issue.Number,
+				slug,
+			))
 		}
+		slugs[slug] = issue.Number
 	}
 
-	if len(articles) > 0 {
-		err = generateBlogIndexPage(articles, cfg, testOutputWriter)
+	discard := func(filename string, render func(io.Writer) error) error {
+		err := render(io.Discard)
 		if err != nil {
-			return err
+			problems = append(problems, filename+": "+err.Error())
 		}
+
+		return nil
+	}
+
+	parsedIssues, err := addImageVariants(allOutdated(parsedIssues), cfg)
+	if err != nil {
+		return append(problems, err.Error())
+	}
+
+	_, err = generateBlog(parsedIssues, cfg, discard, false)
+	if err != nil {
+		problems = append(problems, err.Error())
 	}
 
-	isTest := testOutputWriter != nil
-	if !isTest {
-		err = moveGeneratedFilesToOutputDir(cfg)
+	return problems
+}
+
+// DryRun renders the blog without writing anything and reports to out which
+// files would be created, updated or deleted, along with the problems found.
+// An error is returned if there is any problem.
+func DryRun(
+	parsedIssues []parser.ParsedIssue,
+	postsHistory history.History,
+	next history.History,
+	cfg config.Config,
+	out io.Writer,
+) error {
+	var created, updated []string
+	for _, issue := range parsedIssues {
+		if issue.IsUpToDate {
+			continue
+		}
+
+		slug := issue.Metadata.Slug
+		previous, ok := postsHistory.Lookup(issue.Number, slug)
+		if ok && previous.Slug == slug {
+			updated = append(updated, slug+".html")
+		} else {
+			created = append(created, slug+".html")
+		}
+	}
+
+	static, err := diffStaticFiles(postsHistory, &next, cfg)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range static {
+		if _, ok := postsHistory.Static[file]; ok {
+			updated = append(updated, file)
+		} else {
+			created = append(created, file)
+		}
+	}
+
+	upToDate, err := isUpToDate(parsedIssues, postsHistory, &next, cfg)
+	if err != nil {
+		return err
+	}
+
+	if !upToDate {
+		index := filepath.Base(cfg.TemplateIndexFilePath)
+		_, err = os.Stat(filepath.Join(cfg.OutputDir, index))
 		if err != nil {
-			return err
+			created = append(created, index)
+		} else {
+			updated = append(updated, index)
+		}
+	}
+
+	// Outdated posts get their image variants back, as in GenerateBlogPosts
+	withVariants, err := addImageVariants(parsedIssues, cfg)
+	if err != nil {
+		return err
+	}
+
+	next = next.Clone()
+	recordOutputFiles(withVariants, next)
+
+	problems := Validate(parsedIssues, cfg)
+
+	report := []struct {
+		title string
+		files []string
+	}{
+		{"Created", created},
+		{"Updated", updated},
+		{"Deleted", postsHistory.Stale(next)},
+		{"Problems", problems},
+	}
+
+	for _, section := range report {
+		fmt.Fprintf(out, "%s (%d):\n", section.title, len(section.files))
+		for _, file := range section.files {
+			fmt.Fprintf(out, "  - %s\n", file)
 		}
 	}
 
+	if len(problems) > 0 {
+		return fmt.Errorf("%d problem(s) found", len(problems))
+	}
+
 	return nil
 }
+
+// Clean removes every file generated from the posts recorded in postsHistory,
+// the blog index page and the history file itself.
+func Clean(postsHistory history.History, cfg config.Config) error {
+	files := append(
+		postsHistory.OutputFiles(),
+		filepath.Base(cfg.TemplateIndexFilePath),
+	)
+
+	for _, file := range files {
+		err := os.Remove(filepath.Join(cfg.OutputDir, file))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		log.Println("Removed", file)
+	}
+
+	err := os.RemoveAll(cfg.TempDir)
+	if err != nil {
+		return err
+	}
+
+	return history.RemoveHistoryFile(cfg)
+}
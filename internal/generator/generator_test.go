@@ -3,13 +3,18 @@ package generator
 import (
 	"bytes"
 	"html/template"
+	"image"
+	"image/png"
+	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/ByteBakersCo/babilema/internal/config"
+	"github.com/ByteBakersCo/babilema/internal/history"
 	"github.com/ByteBakersCo/babilema/internal/parser"
 )
 
@@ -37,6 +42,8 @@ func TestGenerateBlogPosts(t *testing.T) {
 	var buf bytes.Buffer
 	err := GenerateBlogPosts(
 		parsedFiles,
+		history.New(),
+		history.New(),
 		config.Config{
 			TemplatePostFilePath: filepath.Join(
 				basePath,
@@ -111,6 +118,41 @@ This is a test with an image <img href="foo.jpg" alt="an image" />
 	}
 }
 
+func TestPreviewIsEscaped(t *testing.T) {
+	// Code spans are decoded to plain text, which must not become HTML
+	preview := extractPlainText(
+		template.HTML("<p><code>&lt;script&gt;alert(1)&lt;/script&gt;" +
+			"</code></p>"),
+	)
+
+	var buf bytes.Buffer
+	err := generateBlogIndexPage(
+		[]article{{Title: "Test Title", Preview: preview}},
+		config.Config{
+			TemplateIndexFilePath: filepath.Join("test-data", "index.html"),
+			TemplateHeaderFilePath: filepath.Join(
+				"test-data",
+				"header.html",
+			),
+			TemplateFooterFilePath: filepath.Join(
+				"test-data",
+				"footer.html",
+			),
+			OutputDir:  filepath.Join(".", "test-data"),
+			WebsiteURL: "https://localhost:8080/foo",
+		},
+		streamWriter(&buf),
+	)
+	if err != nil {
+		t.Fatalf("failed to generate blog index page: %s", err)
+	}
+
+	if strings.Contains(buf.String(), "<script>") ||
+		!strings.Contains(buf.String(), "&lt;script&gt;alert(1)") {
+		t.Errorf("Expected the preview to be escaped, got %s", buf.String())
+	}
+}
+
 func TestGenerateBlogIndexPage(t *testing.T) {
 	articles := []article{
 		{
@@ -152,7 +194,7 @@ func TestGenerateBlogIndexPage(t *testing.T) {
 			OutputDir:  filepath.Join(".", "test-data"),
 			WebsiteURL: "https://localhost:8080/foo",
 		},
-		&buf,
+		streamWriter(&buf),
 	)
 	if err != nil {
 		t.Fatalf("failed to generate blog post: %s", err)
@@ -200,3 +242,345 @@ func TestGenerateBlogIndexPage(t *testing.T) {
 		)
 	}
 }
+
+func TestDryRun(t *testing.T) {
+	_, file, _, _ := runtime.Caller(0)
+	basePath := filepath.Join(filepath.Dir(file), "test-data")
+	cfg := config.Config{
+		TemplatePostFilePath:   filepath.Join(basePath, "post.html"),
+		TemplateHeaderFilePath: filepath.Join(basePath, "header.html"),
+		TemplateFooterFilePath: filepath.Join(basePath, "footer.html"),
+		TemplateIndexFilePath:  filepath.Join(basePath, "index.html"),
+		OutputDir:              basePath,
+		TempDir:                filepath.Join(basePath, "tmp"),
+		WebsiteURL:             "http://localhost:8080/foo",
+	}
+
+	parsedIssues := []parser.ParsedIssue{
+		{Number: 1, Metadata: parser.Metadata{Slug: "foo"}},
+		{Number: 2, Metadata: parser.Metadata{Slug: "bar"}},
+		{Number: 3, Metadata: parser.Metadata{Slug: "bar"}},
+	}
+
+	previous := history.New()
+	previous.Set(1, history.Post{
+		Slug:        "foo",
+		OutputFiles: []string{"foo.html"},
+	})
+	previous.Set(4, history.Post{
+		Slug:        "baz",
+		OutputFiles: []string{"baz.html"},
+	})
+
+	next := history.New()
+	for _, issue := range parsedIssues {
+		next.Set(issue.Number, history.Post{
+			Slug:        issue.Metadata.Slug,
+			OutputFiles: []string{issue.Metadata.Slug + ".html"},
+		})
+	}
+
+	var buf bytes.Buffer
+	err := DryRun(parsedIssues, previous, next, cfg, &buf)
+	if err == nil {
+		t.Error("Expected duplicated slugs to be reported as a problem")
+	}
+
+	expected := `Created (2):
+  - bar.html
+  - bar.html
+Updated (2):
+  - foo.html
+  - index.html
+Deleted (1):
+  - baz.html
+Problems (1):
+  - issues #2 and #3 share the slug "bar"
+`
+	if buf.String() != expected {
+		t.Errorf("Expected report to be\n%s\ngot\n%s", expected, buf.String())
+	}
+
+	if _, err := os.Stat(cfg.TempDir); err == nil {
+		t.Error("Expected dry run not to write anything")
+	}
+}
+
+func TestDryRunFirstBuild(t *testing.T) {
+	_, file, _, _ := runtime.Caller(0)
+	basePath := filepath.Join(filepath.Dir(file), "test-data")
+	outputDir := t.TempDir()
+	cfg := config.Config{
+		TemplatePostFilePath:   filepath.Join(basePath, "post.html"),
+		TemplateHeaderFilePath: filepath.Join(basePath, "header.html"),
+		TemplateFooterFilePath: filepath.Join(basePath, "footer.html"),
+		TemplateIndexFilePath:  filepath.Join(basePath, "index.html"),
+		OutputDir:              outputDir,
+		TempDir:                filepath.Join(outputDir, "tmp"),
+		WebsiteURL:             "http://localhost:8080",
+		ResponsiveImages:       true,
+		ImageWidths:            []int{100},
+	}
+
+	var buf bytes.Buffer
+	err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 200, 100)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(filepath.Join(outputDir, "photo.png"), buf.Bytes(), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsedIssues := []parser.ParsedIssue{{
+		Number:   1,
+		Metadata: parser.Metadata{Slug: "foo", Image: "photo.png"},
+	}}
+
+	// The previous build published the image variants of the post
+	previous := history.New()
+	previous.Set(1, history.Post{Slug: "foo"})
+	withVariants, err := addImageVariants(parsedIssues, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recordOutputFiles(withVariants, previous)
+
+	next := history.New()
+	next.Set(1, history.Post{Slug: "foo", OutputFiles: []string{"foo.html"}})
+
+	buf.Reset()
+	err = DryRun(parsedIssues, previous, next, cfg, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `Created (1):
+  - index.html
+Updated (1):
+  - foo.html
+Deleted (0):
+Problems (0):
+`
+	if buf.String() != expected {
+		t.Errorf("Expected report to be\n%s\ngot\n%s", expected, buf.String())
+	}
+}
+
+func TestClean(t *testing.T) {
+	outputDir := t.TempDir()
+	cfg := config.Config{
+		OutputDir:             outputDir,
+		TempDir:               filepath.Join(outputDir, "tmp"),
+		TemplateIndexFilePath: filepath.Join("templates", "index.html"),
+	}
+
+	postsHistory := history.New()
+	postsHistory.Set(1, history.Post{
+		Slug:        "foo",
+		OutputFiles: []string{"foo.html"},
+	})
+
+	err := history.UpdateHistoryFile(postsHistory, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range []string{"foo.html", "index.html", "keep.html"} {
+		err = os.WriteFile(filepath.Join(outputDir, file), nil, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = Clean(postsHistory, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, _ := os.ReadDir(outputDir)
+	if len(entries) != 1 || entries[0].Name() != "keep.html" {
+		t.Errorf("Expected only keep.html to be left, got %v", entries)
+	}
+}
+
+func TestDefaultThemeFallback(t *testing.T) {
+	dir := t.TempDir()
+	parsedFiles := []parser.ParsedIssue{
+		{
+			Metadata: parser.Metadata{Title: "Test Title", Slug: "test"},
+			Content:  template.HTML("<h1>Test HTML</h1>"),
+		},
+	}
+
+	var buf bytes.Buffer
+	err := GenerateBlogPosts(
+		parsedFiles,
+		history.New(),
+		history.New(),
+		config.Config{
+			TemplatePostFilePath:   filepath.Join(dir, "post.html"),
+			TemplateHeaderFilePath: filepath.Join(dir, "header.html"),
+			TemplateFooterFilePath: filepath.Join(dir, "footer.html"),
+			TemplateIndexFilePath:  filepath.Join(dir, "index.html"),
+			CSSDir:                 filepath.Join(dir, "css"),
+			OutputDir:              dir,
+			WebsiteURL:             "http://localhost:8080",
+		},
+		&buf,
+	)
+	if err != nil {
+		t.Fatalf("failed to generate blog post: %s", err)
+	}
+
+	output := buf.String()
+	expected := []string{
+		"<title>Test Title</title>",
+		"<h1>Test HTML</h1>",
+		"Powered by",
+		`/css/style.css">`,
+	}
+	for _, s := range expected {
+		if !strings.Contains(output, s) {
+			t.Errorf("Expected output to contain %q, got %s", s, output)
+		}
+	}
+}
+
+func TestThemeOverride(t *testing.T) {
+	siteDir := t.TempDir()
+	themeDir := t.TempDir()
+
+	files := map[string]string{
+		filepath.Join(themeDir, "post.html"): "{{.Header}}|{{.Footer}}|" +
+			"{{range .CSSLinks}}{{.}}{{end}}",
+		filepath.Join(themeDir, "header.html"):      "Theme header",
+		filepath.Join(themeDir, "footer.html"):      "Theme footer",
+		filepath.Join(themeDir, "css", "theme.css"): "body{}",
+		filepath.Join(siteDir, "footer.html"):       "Site footer",
+	}
+	for path, content := range files {
+		err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = os.WriteFile(path, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	err := GenerateBlogPosts(
+		[]parser.ParsedIssue{{Metadata: parser.Metadata{Slug: "test"}}},
+		history.New(),
+		history.New(),
+		config.Config{
+			TemplatePostFilePath:   filepath.Join(siteDir, "post.html"),
+			TemplateHeaderFilePath: filepath.Join(siteDir, "header.html"),
+			TemplateFooterFilePath: filepath.Join(siteDir, "footer.html"),
+			TemplateIndexFilePath:  filepath.Join(siteDir, "index.html"),
+			CSSDir:                 filepath.Join(siteDir, "css"),
+			OutputDir:              siteDir,
+			Theme:                  themeDir,
+			WebsiteURL:             "http://localhost:8080",
+		},
+		&buf,
+	)
+	if err != nil {
+		t.Fatalf("failed to generate blog post: %s", err)
+	}
+
+	cssLink, _ := fileURL(
+		filepath.Join(siteDir, "css", "theme.css"),
+		config.Config{WebsiteURL: "http://localhost:8080"},
+	)
+	expected := "Theme header|Site footer|" + cssLink
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestExtractJSLinks(t *testing.T) {
+	siteDir := t.TempDir()
+	themeDir := t.TempDir()
+	cfg := config.Config{
+		JSDir:      filepath.Join(siteDir, "js"),
+		JSDefer:    true,
+		OutputDir:  siteDir,
+		Theme:      themeDir,
+		WebsiteURL: "http://localhost:8080",
+	}
+
+	themeScript := filepath.Join(themeDir, "js", "theme.js")
+	err := os.MkdirAll(filepath.Dir(themeScript), os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(themeScript, []byte("// theme"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// js_dir does not exist, the theme's scripts are used
+	links, err := extractJSLinks(cfg.JSDir, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	url, _ := fileURL(filepath.Join(siteDir, "js", "theme.js"), cfg)
+	expected := []scriptLink{{URL: url, Defer: true}}
+	if !reflect.DeepEqual(links, expected) {
+		t.Errorf("Expected %v, got %v", expected, links)
+	}
+
+	siteScript := filepath.Join(cfg.JSDir, "site.js")
+	err = os.MkdirAll(cfg.JSDir, os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(siteScript, []byte("// site"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	links, err = extractJSLinks(cfg.JSDir, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	url, _ = fileURL(siteScript, cfg)
+	expected = []scriptLink{{URL: url, Defer: true}}
+	if !reflect.DeepEqual(links, expected) {
+		t.Errorf("Expected %v, got %v", expected, links)
+	}
+}
+
+func TestPostJSLinks(t *testing.T) {
+	jsLinks := []scriptLink{{URL: "/js/site.js"}}
+	cfg := config.Config{MermaidScriptURL: "/js/mermaid.min.js"}
+
+	links := postJSLinks(jsLinks, parser.ParsedIssue{}, cfg)
+	if !reflect.DeepEqual(links, jsLinks) {
+		t.Errorf("Expected %v, got %v", jsLinks, links)
+	}
+
+	links = postJSLinks(jsLinks, parser.ParsedIssue{HasMermaid: true}, cfg)
+	expected := []scriptLink{
+		{URL: "/js/site.js"},
+		{URL: "/js/mermaid.min.js", Defer: true},
+	}
+	if !reflect.DeepEqual(links, expected) {
+		t.Errorf("Expected %v, got %v", expected, links)
+	}
+
+	// The scripts of the other posts are left as is
+	if len(jsLinks) != 1 {
+		t.Errorf("Expected jsLinks to be unchanged, got %v", jsLinks)
+	}
+}
@@ -0,0 +1,407 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"github.com/ByteBakersCo/babilema/internal/config"
+	"github.com/ByteBakersCo/babilema/internal/history"
+	"github.com/ByteBakersCo/babilema/internal/parser"
+	"github.com/ByteBakersCo/babilema/internal/utils"
+)
+
+// Used when cfg.ImageWidths or cfg.ImageSizes are not set
+var defaultImageWidths = []int{480, 960, 1440}
+
+const defaultImageSizes string = "100vw"
+
+// responsiveImage holds the attributes of an <img> tag showing a local image
+// and its resized variants.
+type responsiveImage struct {
+	Srcset string
+	Sizes  string
+	Width  int
+	Height int
+}
+
+// imageVariant is a resized copy of an image.
+type imageVariant struct {
+	// Relative to cfg.OutputDir
+	path  string
+	width int
+}
+
+// localImage returns the content of the image at src, as linked from a page
+// of cfg.OutputDir. Images are looked up in the post's assets (they are not
+// published yet), then on disk.
+func localImage(
+	src string,
+	issue parser.ParsedIssue,
+	cfg config.Config,
+) ([]byte, bool) {
+	srcURL, err := url.Parse(src)
+	if err != nil {
+		return nil, false
+	}
+
+	websiteURL, err := url.Parse(cfg.WebsiteURL)
+	if err != nil {
+		return nil, false
+	}
+
+	var file string
+	switch {
+	case srcURL.Scheme == "" && srcURL.Host == "" &&
+		!strings.HasPrefix(srcURL.Path, "/"):
+		file = filepath.Join(cfg.OutputDir, filepath.FromSlash(srcURL.Path))
+
+	case srcURL.Host == "" || srcURL.Host == websiteURL.Host:
+		prefix := strings.TrimSuffix(websiteURL.Path, "/") + "/"
+		relativePath, ok := strings.CutPrefix(srcURL.Path, prefix)
+		if !ok {
+			return nil, false
+		}
+
+		rootDir, err := utils.RootDir()
+		if err != nil {
+			return nil, false
+		}
+
+		file = filepath.Join(rootDir, filepath.FromSlash(relativePath))
+
+	default:
+		return nil, false
+	}
+
+	for _, asset := range issue.Assets {
+		if filepath.Join(cfg.OutputDir, asset.Path) == file {
+			return asset.Content, true
+		}
+	}
+
+	content, err := os.ReadFile(file)
+
+	return content, err == nil
+}
+
+// imageVariants returns the size and format of the JPEG or PNG image, along
+// with the variants to generate for it (one per configured width smaller
+// than the image).
+func imageVariants(
+	content []byte,
+	cfg config.Config,
+) (image.Config, string, []imageVariant, bool) {
+	imageCfg, format, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil || (format != "jpeg" && format != "png") {
+		return image.Config{}, "", nil, false
+	}
+
+	widths := cfg.ImageWidths
+	if len(widths) == 0 {
+		widths = defaultImageWidths
+	}
+
+	ext := ".png"
+	if format == "jpeg" {
+		ext = ".jpg"
+	}
+
+	hash := history.Hash(content)[:16]
+
+	var variants []imageVariant
+	for _, width := range widths {
+		if width <= 0 || width >= imageCfg.Width {
+			continue
+		}
+
+		name := fmt.Sprintf("%s-%dw%s", hash, width, ext)
+		variants = append(variants, imageVariant{
+			path:  filepath.Join(filepath.FromSlash(parser.ImagesDir), name),
+			width: width,
+		})
+	}
+
+	sort.Slice(variants, func(i, j int) bool {
+		return variants[i].width < variants[j].width
+	})
+
+	return imageCfg, format, variants, true
+}
+
+// describeImage returns the attributes showing the local image at src in a
+// responsive way, or nil if it is not a local JPEG or PNG image.
+func describeImage(
+	src string,
+	issue parser.ParsedIssue,
+	cfg config.Config,
+) *responsiveImage {
+	content, ok := localImage(src, issue, cfg)
+	if !ok {
+		return nil
+	}
+
+	imageCfg, _, variants, ok := imageVariants(content, cfg)
+	if !ok {
+		return nil
+	}
+
+	var srcset []string
+	for _, variant := range variants {
+		srcset = append(srcset, fmt.Sprintf(
+			"%s %dw",
+			filepath.ToSlash(variant.path),
+			variant.width,
+		))
+	}
+
+	srcset = append(srcset, fmt.Sprintf("%s %dw", src, imageCfg.Width))
+
+	sizes := cfg.ImageSizes
+	if sizes == "" {
+		sizes = defaultImageSizes
+	}
+
+	return &responsiveImage{
+		Srcset: strings.Join(srcset, ", "),
+		Sizes:  sizes,
+		Width:  imageCfg.Width,
+		Height: imageCfg.Height,
+	}
+}
+
+// resize scales img down to the given width, each pixel being the average of
+// the pixels it covers in img.
+func resize(img image.Image, width int) image.Image {
+	bounds := img.Bounds()
+	height := max(1, bounds.Dy()*width/bounds.Dx())
+	resized := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*bounds.Dy()/height)
+
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := max(x0+1, bounds.Min.X+(x+1)*bounds.Dx()/width)
+
+			var r, g, b, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := img.At(sx, sy).RGBA()
+					r, g, b, a = r+pr>>8, g+pg>>8, b+pb>>8, a+pa>>8
+					n++
+				}
+			}
+
+			resized.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n),
+				G: uint8(g / n),
+				B: uint8(b / n),
+				A: uint8(a / n),
+			})
+		}
+	}
+
+	return resized
+}
+
+// generateVariants returns the resized variants of the image as assets.
+func generateVariants(
+	content []byte,
+	cfg config.Config,
+) ([]parser.Asset, error) {
+	_, format, variants, ok := imageVariants(content, cfg)
+	if !ok || len(variants) == 0 {
+		return nil, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+
+	// Each variant is resized from the next larger one, which is faster
+	// than starting from the original image every time
+	var assets []parser.Asset
+	for i := len(variants) - 1; i >= 0; i-- {
+		img = resize(img, variants[i].width)
+
+		var buf bytes.Buffer
+		if format == "jpeg" {
+			err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+		} else {
+			err = png.Encode(&buf, img)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		assets = append(assets, parser.Asset{
+			Path:    variants[i].path,
+			Content: buf.Bytes(),
+		})
+	}
+
+	return assets, nil
+}
+
+// hasAttr tells whether the token has the attribute key.
+func hasAttr(token html.Token, key string) bool {
+	for _, attr := range token.Attr {
+		if attr.Key == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// addResponsiveAttrs adds the srcset, sizes, width and height attributes to
+// the <img> tags of the post showing local images. It returns the updated
+// content and the source of every image.
+func addResponsiveAttrs(
+	issue parser.ParsedIssue,
+	cfg config.Config,
+) (template.HTML, []string) {
+	var sources []string
+
+	content := utils.RewriteTags(
+		[]byte(issue.Content),
+		func(token *html.Token) bool {
+			isImage := token.Data == "img" &&
+				(token.Type == html.StartTagToken ||
+					token.Type == html.SelfClosingTagToken)
+
+			var img *responsiveImage
+			for _, attr := range token.Attr {
+				if isImage && attr.Key == "src" {
+					img = describeImage(attr.Val, issue, cfg)
+					sources = append(sources, attr.Val)
+				}
+			}
+
+			if img == nil || hasAttr(*token, "srcset") {
+				return false
+			}
+
+			token.Attr = append(
+				token.Attr,
+				html.Attribute{Key: "srcset", Val: img.Srcset},
+				html.Attribute{Key: "sizes", Val: img.Sizes},
+			)
+
+			if !hasAttr(*token, "width") && !hasAttr(*token, "height") {
+				token.Attr = append(
+					token.Attr,
+					html.Attribute{Key: "width", Val: fmt.Sprint(img.Width)},
+					html.Attribute{
+						Key: "height",
+						Val: fmt.Sprint(img.Height),
+					},
+				)
+			}
+
+			return true
+		},
+	)
+
+	return template.HTML(content), sources
+}
+
+// addImageVariants generates the resized variants of the local images of the
+// outdated posts (in their body and Metadata.Image) and adds them to their
+// assets.
+func addImageVariants(
+	parsedIssues []parser.ParsedIssue,
+	cfg config.Config,
+) ([]parser.ParsedIssue, error) {
+	if !cfg.ResponsiveImages {
+		return parsedIssues, nil
+	}
+
+	issues := make([]parser.ParsedIssue, len(parsedIssues))
+	for i, issue := range parsedIssues {
+		issues[i] = issue
+		if issue.IsUpToDate {
+			continue
+		}
+
+		body, sources := addResponsiveAttrs(issue, cfg)
+
+		if issue.Metadata.Image != "" {
+			sources = append(sources, issue.Metadata.Image)
+		}
+
+		assets := append([]parser.Asset{}, issue.Assets...)
+		seen := make(map[string]bool)
+		for _, src := range sources {
+			content, ok := localImage(src, issue, cfg)
+			if !ok || seen[src] {
+				continue
+			}
+
+			seen[src] = true
+			variants, err := generateVariants(content, cfg)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", src, err)
+			}
+
+			assets = append(assets, variants...)
+		}
+
+		issues[i].Content = body
+		issues[i].Assets = assets
+	}
+
+	return issues, nil
+}
+
+// recordOutputFiles records the page and assets of the outdated posts in
+// next.
+func recordOutputFiles(
+	parsedIssues []parser.ParsedIssue,
+	next history.History,
+) {
+	for _, issue := range parsedIssues {
+		post, ok := next.Posts[issue.Number]
+		if issue.IsUpToDate || !ok {
+			continue
+		}
+
+		post.OutputFiles = []string{issue.Metadata.Slug + ".html"}
+		seen := make(map[string]bool)
+		for _, asset := range issue.Assets {
+			if !seen[asset.Path] {
+				seen[asset.Path] = true
+				post.OutputFiles = append(post.OutputFiles, asset.Path)
+			}
+		}
+
+		next.Posts[issue.Number] = post
+	}
+}
+
+// previewImage returns the responsive attributes of the post's preview
+// image (Metadata.Image) for the blog index page.
+func previewImage(
+	issue parser.ParsedIssue,
+	cfg config.Config,
+) *responsiveImage {
+	if !cfg.ResponsiveImages || issue.Metadata.Image == "" {
+		return nil
+	}
+
+	return describeImage(issue.Metadata.Image, issue, cfg)
+}
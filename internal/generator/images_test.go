@@ -0,0 +1,116 @@
+package generator
+
+import (
+	"bytes"
+	"html/template"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ByteBakersCo/babilema/internal/config"
+	"github.com/ByteBakersCo/babilema/internal/history"
+	"github.com/ByteBakersCo/babilema/internal/parser"
+)
+
+func TestAddImageVariants(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.Config{
+		OutputDir:        dir,
+		WebsiteURL:       "http://localhost:8080",
+		ResponsiveImages: true,
+		ImageWidths:      []int{1000, 500, 4000},
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, 2000, 1000))
+	for x := 0; x < 2000; x++ {
+		img.Set(x, 500, color.RGBA{R: 255, A: 255})
+	}
+
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(filepath.Join(dir, "photo.png"), buf.Bytes(), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsedIssues := []parser.ParsedIssue{
+		{
+			Number:   1,
+			Metadata: parser.Metadata{Slug: "test", Image: "photo.png"},
+			Content: template.HTML(`<p><img src="photo.png" alt="Photo">` +
+				`<img src="https://example.com/remote.png" alt="Remote"></p>`),
+		},
+	}
+
+	issues, err := addImageVariants(parsedIssues, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assets := issues[0].Assets
+	if len(assets) != 2 {
+		t.Fatalf("Expected 2 variants, got %d", len(assets))
+	}
+
+	for i, width := range []int{1000, 500} {
+		imageCfg, _, err := image.DecodeConfig(
+			bytes.NewReader(assets[i].Content),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if imageCfg.Width != width || imageCfg.Height != width/2 {
+			t.Errorf(
+				"Expected a %dx%d variant, got %dx%d",
+				width,
+				width/2,
+				imageCfg.Width,
+				imageCfg.Height,
+			)
+		}
+	}
+
+	srcset := filepath.ToSlash(assets[1].Path) + " 500w, " +
+		filepath.ToSlash(assets[0].Path) + " 1000w, photo.png 2000w"
+	expected := []string{
+		`srcset="` + srcset + `"`,
+		`sizes="100vw"`,
+		`width="2000" height="1000"`,
+		`<img src="https://example.com/remote.png" alt="Remote">`,
+	}
+
+	content := string(issues[0].Content)
+	for _, s := range expected {
+		if !strings.Contains(content, s) {
+			t.Errorf("Expected content to contain %q, got %s", s, content)
+		}
+	}
+
+	next := history.New()
+	next.Set(1, history.Post{Slug: "test"})
+	recordOutputFiles(issues, next)
+
+	outputFiles := []string{"test.html", assets[0].Path, assets[1].Path}
+	if !reflect.DeepEqual(next.Posts[1].OutputFiles, outputFiles) {
+		t.Errorf(
+			"Expected output files %v, got %v",
+			outputFiles,
+			next.Posts[1].OutputFiles,
+		)
+	}
+
+	preview := previewImage(issues[0], cfg)
+	if preview == nil || preview.Srcset != srcset {
+		t.Errorf("Expected the preview image srcset to be %q", srcset)
+	}
+}
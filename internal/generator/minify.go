@@ -0,0 +1,100 @@
+package generator
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"path/filepath"
+	"regexp"
+
+	"golang.org/x/net/html"
+)
+
+var whitespace = regexp.MustCompile(`[ \t\r\n\f]+`)
+
+// preformatted are the elements whose content is left untouched by
+// minifyHTML.
+var preformatted = map[string]bool{
+	"pre":      true,
+	"textarea": true,
+	"script":   true,
+	"style":    true,
+}
+
+// minifyHTML removes the comments and collapses the whitespace of page,
+// except in preformatted elements.
+func minifyHTML(page []byte) ([]byte, error) {
+	var out bytes.Buffer
+	depth := 0
+
+	tokenizer := html.NewTokenizer(bytes.NewReader(page))
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			if errors.Is(tokenizer.Err(), io.EOF) {
+				break
+			}
+
+			return nil, tokenizer.Err()
+		}
+
+		raw := tokenizer.Raw()
+		switch tokenType {
+		case html.CommentToken:
+			continue
+
+		case html.StartTagToken:
+			name, _ := tokenizer.TagName()
+			if preformatted[string(name)] {
+				depth++
+			}
+
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			if preformatted[string(name)] && depth > 0 {
+				depth--
+			}
+
+		case html.TextToken:
+			if depth > 0 {
+				break
+			}
+
+			// Removed comments can leave two spaces side by side
+			raw = whitespace.ReplaceAll(raw, []byte(" "))
+			if out.Len() == 0 || bytes.HasSuffix(out.Bytes(), []byte(" ")) {
+				raw = bytes.TrimLeft(raw, " ")
+			}
+		}
+
+		out.Write(raw)
+	}
+
+	return bytes.TrimRight(out.Bytes(), " "), nil
+}
+
+// minifyingWriter minifies the HTML pages before writing them with write.
+func minifyingWriter(write pageWriter) pageWriter {
+	return func(filename string, render func(io.Writer) error) error {
+		if filepath.Ext(filename) != ".html" {
+			return write(filename, render)
+		}
+
+		return write(filename, func(writer io.Writer) error {
+			var buf bytes.Buffer
+			err := render(&buf)
+			if err != nil {
+				return err
+			}
+
+			page, err := minifyHTML(buf.Bytes())
+			if err != nil {
+				return err
+			}
+
+			_, err = writer.Write(page)
+
+			return err
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package generator
+
+import (
+	"testing"
+)
+
+func TestMinifyHTML(t *testing.T) {
+	page := `
+<!DOCTYPE html>
+<html>
+<!-- Comment -->
+<head>
+    <style>
+        body { margin: 0; }
+    </style>
+</head>
+
+<body>
+    <p>Some   <b>bold</b>
+       text</p>
+
+
+    <pre><code>func main() {
+    fmt.Println("Hello")
+}</code></pre>
+</body>
+</html>
+`
+
+	expected := `<!DOCTYPE html> <html> <head> <style>
+        body { margin: 0; }
+    </style> </head> <body> <p>Some <b>bold</b> text</p> ` +
+		`<pre><code>func main() {
+    fmt.Println("Hello")
+}</code></pre> </body> </html>`
+
+	actual, err := minifyHTML([]byte(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(actual) != expected {
+		t.Errorf("Expected %q, got %q", expected, actual)
+	}
+}
@@ -0,0 +1,212 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/ByteBakersCo/babilema/internal/config"
+	"github.com/ByteBakersCo/babilema/internal/history"
+)
+
+// transaction keeps track of the changes made to cfg.OutputDir while
+// publishing, so that they can be rolled back.
+type transaction struct {
+	cfg       config.Config
+	backupDir string
+
+	// Files moved from cfg.OutputDir to backupDir
+	backedUp []string
+
+	// Files moved from cfg.TempDir to cfg.OutputDir
+	published []string
+}
+
+// verifyStagedFiles makes sure every generated file was staged, and that
+// pages are not empty. Other assets (e.g. a placeholder stylesheet of a
+// theme) can legitimately be empty.
+func verifyStagedFiles(generated []string, cfg config.Config) error {
+	for _, file := range generated {
+		info, err := os.Stat(filepath.Join(cfg.TempDir, file))
+		if err != nil {
+			return fmt.Errorf("missing generated file %s: %w", file, err)
+		}
+
+		if info.Size() == 0 && filepath.Ext(file) == ".html" {
+			return fmt.Errorf("generated page %s is empty", file)
+		}
+	}
+
+	return nil
+}
+
+func rename(src string, dest string) error {
+	err := os.MkdirAll(filepath.Dir(dest), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(src, dest)
+}
+
+func (tx *transaction) backup(file string) error {
+	src := filepath.Join(tx.cfg.OutputDir, file)
+	if _, err := os.Lstat(src); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	err := rename(src, filepath.Join(tx.backupDir, file))
+	if err != nil {
+		return err
+	}
+
+	tx.backedUp = append(tx.backedUp, file)
+
+	return nil
+}
+
+func (tx *transaction) publish(file string) error {
+	err := rename(
+		filepath.Join(tx.cfg.TempDir, file),
+		filepath.Join(tx.cfg.OutputDir, file),
+	)
+	if err != nil {
+		return err
+	}
+
+	tx.published = append(tx.published, file)
+
+	return nil
+}
+
+// rollback puts the generated files back in cfg.TempDir (so they can still
+// be inspected) and restores cfg.OutputDir to its previous state.
+func (tx *transaction) rollback() error {
+	var errs []error
+	for i := len(tx.published) - 1; i >= 0; i-- {
+		file := tx.published[i]
+		err := rename(
+			filepath.Join(tx.cfg.OutputDir, file),
+			filepath.Join(tx.cfg.TempDir, file),
+		)
+		errs = append(errs, err)
+	}
+
+	for i := len(tx.backedUp) - 1; i >= 0; i-- {
+		file := tx.backedUp[i]
+		err := rename(
+			filepath.Join(tx.backupDir, file),
+			filepath.Join(tx.cfg.OutputDir, file),
+		)
+		errs = append(errs, err)
+	}
+
+	err := errors.Join(errs...)
+	if err == nil {
+		err = os.RemoveAll(tx.backupDir)
+	}
+
+	return err
+}
+
+// commit archives the stale files (if cfg.ArchiveDir is set) and removes the
+// backups and cfg.TempDir.
+func (tx *transaction) commit(stale []string) error {
+	if tx.cfg.ArchiveDir != "" {
+		for _, file := range stale {
+			src := filepath.Join(tx.backupDir, file)
+			if _, err := os.Lstat(src); errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+
+			log.Println("Archiving blog post:", file)
+			err := rename(src, filepath.Join(tx.cfg.ArchiveDir, file))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err := os.RemoveAll(tx.backupDir)
+	if err != nil {
+		return err
+	}
+
+	return os.RemoveAll(tx.cfg.TempDir)
+}
+
+// publish moves the generated and static files staged in cfg.TempDir to
+// cfg.OutputDir, removes the stale ones and records the next history. Other
+// files of cfg.TempDir are left behind.
+// Every file replaced or removed is backed up first, so that cfg.OutputDir is
+// left untouched if anything fails. The history file is only written once
+// all the files are in place.
+func publish(
+	generated []string,
+	static []string,
+	stale []string,
+	next history.History,
+	cfg config.Config,
+) error {
+	err := verifyStagedFiles(generated, cfg)
+	if err != nil {
+		return err
+	}
+
+	staged := append(append([]string{}, generated...), static...)
+
+	err = os.MkdirAll(cfg.OutputDir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	backupDir, err := os.MkdirTemp(cfg.OutputDir, ".babilema-backup-")
+	if err != nil {
+		return err
+	}
+
+	tx := &transaction{cfg: cfg, backupDir: backupDir}
+
+	err = tx.swap(staged, stale)
+	if err == nil {
+		err = history.UpdateHistoryFile(next, cfg)
+	}
+
+	if err != nil {
+		log.Println("Publishing failed, rolling back...")
+		rollbackErr := tx.rollback()
+		if rollbackErr != nil {
+			return errors.Join(err, rollbackErr)
+		}
+
+		return err
+	}
+
+	return tx.commit(stale)
+}
+
+func (tx *transaction) swap(staged []string, stale []string) error {
+	for _, file := range stale {
+		log.Println("Removing stale file:", file)
+		err := tx.backup(file)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, file := range staged {
+		err := tx.backup(file)
+		if err != nil {
+			return err
+		}
+
+		err = tx.publish(file)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
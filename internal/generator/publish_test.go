@@ -0,0 +1,176 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ByteBakersCo/babilema/internal/config"
+	"github.com/ByteBakersCo/babilema/internal/history"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for file, content := range files {
+		path := filepath.Join(dir, file)
+		err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = os.WriteFile(path, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func checkFile(t *testing.T, path string, expected string) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Errorf("Expected %s to exist: %s", path, err)
+		return
+	}
+
+	if string(content) != expected {
+		t.Errorf("Expected %s to be %q, got %q", path, expected, content)
+	}
+}
+
+func TestPublish(t *testing.T) {
+	root := t.TempDir()
+	cfg := config.Config{
+		OutputDir:  filepath.Join(root, "output"),
+		TempDir:    filepath.Join(root, "tmp"),
+		ArchiveDir: filepath.Join(root, "archive"),
+	}
+
+	writeFiles(t, cfg.OutputDir, map[string]string{
+		"foo.html": "old foo",
+		"bar.html": "old bar",
+	})
+	writeFiles(t, cfg.TempDir, map[string]string{
+		"foo.html":       "new foo",
+		"index.html":     "index",
+		"css/custom.css": "",
+		"favicon.ico":    "icon",
+		"leftover.html":  "from a failed build",
+	})
+
+	next := history.New()
+	next.Set(1, history.Post{Slug: "foo", OutputFiles: []string{"foo.html"}})
+
+	// Assets other than pages can be empty
+	err := publish(
+		[]string{"foo.html", "index.html", filepath.Join("css", "custom.css")},
+		[]string{"favicon.ico"},
+		[]string{"bar.html"},
+		next,
+		cfg,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkFile(t, filepath.Join(cfg.OutputDir, "foo.html"), "new foo")
+	checkFile(t, filepath.Join(cfg.OutputDir, "index.html"), "index")
+	checkFile(t, filepath.Join(cfg.OutputDir, "css", "custom.css"), "")
+	checkFile(t, filepath.Join(cfg.OutputDir, "favicon.ico"), "icon")
+	checkFile(t, filepath.Join(cfg.ArchiveDir, "bar.html"), "old bar")
+
+	// Only the files of this build are published
+	_, err = os.Stat(filepath.Join(cfg.OutputDir, "leftover.html"))
+	if err == nil {
+		t.Error("Expected leftover.html not to be published")
+	}
+
+	if _, err := os.Stat(filepath.Join(cfg.OutputDir, "bar.html")); err == nil {
+		t.Error("Expected bar.html to be removed")
+	}
+
+	if _, err := os.Stat(cfg.TempDir); err == nil {
+		t.Error("Expected temp dir to be removed")
+	}
+
+	actual, err := history.ParseHistoryFile(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := actual.Lookup(1, "foo"); !ok {
+		t.Error("Expected history to be committed")
+	}
+
+	entries, _ := os.ReadDir(cfg.OutputDir)
+	if len(entries) != 5 {
+		t.Errorf("Expected no leftover files in output dir, got %v", entries)
+	}
+}
+
+func TestPublishRollback(t *testing.T) {
+	root := t.TempDir()
+	cfg := config.Config{
+		OutputDir: filepath.Join(root, "output"),
+		TempDir:   filepath.Join(root, "tmp"),
+	}
+
+	writeFiles(t, cfg.OutputDir, map[string]string{
+		"foo.html": "old foo",
+		"bar.html": "old bar",
+		"qux":      "not a directory",
+	})
+	writeFiles(t, cfg.TempDir, map[string]string{
+		"foo.html":     "new foo",
+		"qux/baz.html": "cannot be published",
+	})
+
+	err := publish(
+		[]string{"foo.html"},
+		[]string{filepath.Join("qux", "baz.html")},
+		[]string{"bar.html"},
+		history.New(),
+		cfg,
+	)
+	if err == nil {
+		t.Fatal("Expected publishing to fail")
+	}
+
+	checkFile(t, filepath.Join(cfg.OutputDir, "foo.html"), "old foo")
+	checkFile(t, filepath.Join(cfg.OutputDir, "bar.html"), "old bar")
+	checkFile(t, filepath.Join(cfg.OutputDir, "qux"), "not a directory")
+	checkFile(t, filepath.Join(cfg.TempDir, "foo.html"), "new foo")
+
+	_, err = os.Stat(filepath.Join(cfg.OutputDir, ".babilema-history.toml"))
+	if err == nil {
+		t.Error("Expected history not to be written")
+	}
+
+	entries, _ := os.ReadDir(cfg.OutputDir)
+	if len(entries) != 3 {
+		t.Errorf("Expected backups to be cleaned up, got %v", entries)
+	}
+}
+
+func TestPublishMissingFile(t *testing.T) {
+	root := t.TempDir()
+	cfg := config.Config{
+		OutputDir: filepath.Join(root, "output"),
+		TempDir:   filepath.Join(root, "tmp"),
+	}
+
+	writeFiles(t, cfg.TempDir, map[string]string{"foo.html": "foo"})
+
+	err := publish(
+		[]string{"foo.html", "index.html"},
+		nil,
+		nil,
+		history.New(),
+		cfg,
+	)
+	if err == nil {
+		t.Fatal("Expected publishing to fail")
+	}
+
+	if _, err := os.Stat(cfg.OutputDir); err == nil {
+		t.Error("Expected nothing to be published")
+	}
+}
@@ -0,0 +1,131 @@
+package generator
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/ByteBakersCo/babilema/internal/config"
+	"github.com/ByteBakersCo/babilema/internal/history"
+)
+
+// themeStaticDir is the directory of a theme whose content is published
+// like the content of cfg.StaticDir.
+const themeStaticDir string = "static"
+
+// staticDirs returns the directories whose content is published to
+// cfg.OutputDir, by increasing priority.
+func staticDirs(cfg config.Config) []string {
+	var dirs []string
+	if cfg.Theme != "" {
+		dirs = append(dirs, filepath.Join(cfg.Theme, themeStaticDir))
+	}
+
+	if cfg.StaticDir != "" {
+		dirs = append(dirs, cfg.StaticDir)
+	}
+
+	return dirs
+}
+
+// staticFiles returns the path of every static file, by path relative to
+// the static directories. Files of cfg.StaticDir override the theme's ones.
+func staticFiles(cfg config.Config) (map[string]string, error) {
+	files := make(map[string]string)
+	for _, dir := range staticDirs(cfg) {
+		err := filepath.WalkDir(
+			dir,
+			func(path string, entry fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+
+				if entry.IsDir() {
+					return nil
+				}
+
+				relativePath, err := filepath.Rel(dir, path)
+				if err != nil {
+					return err
+				}
+
+				files[relativePath] = path
+
+				return nil
+			},
+		)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+// diffStaticFiles records the hash of every static file (of cfg.StaticDir
+// and of the theme's static directory) in next and returns the ones
+// (relative to their directory) that changed since postsHistory or are
+// missing from cfg.OutputDir.
+func diffStaticFiles(
+	postsHistory history.History,
+	next *history.History,
+	cfg config.Config,
+) ([]string, error) {
+	// Files that are no longer static files become stale
+	next.Static = make(map[string]string)
+
+	files, err := staticFiles(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	for relativePath, path := range files {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		hash := history.Hash(content)
+		next.Static[relativePath] = hash
+
+		_, err = os.Stat(filepath.Join(cfg.OutputDir, relativePath))
+		if postsHistory.Static[relativePath] != hash || err != nil {
+			changed = append(changed, relativePath)
+		}
+	}
+
+	sort.Strings(changed)
+
+	return changed, nil
+}
+
+// copyStaticFiles copies the given static files with write.
+func copyStaticFiles(files []string, cfg config.Config, write pageWriter) error {
+	sources, err := staticFiles(cfg)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		err := write(file, func(writer io.Writer) error {
+			src, err := os.Open(sources[file])
+			if err != nil {
+				return err
+			}
+			defer src.Close()
+
+			_, err = io.Copy(writer, src)
+
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package generator
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ByteBakersCo/babilema/internal/config"
+	"github.com/ByteBakersCo/babilema/internal/history"
+)
+
+func TestDiffStaticFiles(t *testing.T) {
+	root := t.TempDir()
+	cfg := config.Config{
+		OutputDir: filepath.Join(root, "output"),
+		TempDir:   filepath.Join(root, "tmp"),
+		StaticDir: filepath.Join(root, "static"),
+	}
+
+	writeFiles(t, cfg.StaticDir, map[string]string{
+		"favicon.ico":        "icon",
+		"images/cat.png":     "cat",
+		"fonts/.placeholder": "",
+	})
+
+	next := history.New()
+	changed, err := diffStaticFiles(history.New(), &next, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"favicon.ico",
+		filepath.Join("fonts", ".placeholder"),
+		filepath.Join("images", "cat.png"),
+	}
+	if !reflect.DeepEqual(changed, expected) {
+		t.Errorf("Expected %v to change, got %v", expected, changed)
+	}
+
+	err = copyStaticFiles(changed, cfg, fileWriter(cfg.OutputDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkFile(t, filepath.Join(cfg.OutputDir, "images", "cat.png"), "cat")
+
+	// Nothing changed since the files were copied
+	previous := next
+	next = history.New()
+	changed, err = diffStaticFiles(previous, &next, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(changed) != 0 {
+		t.Errorf("Expected nothing to change, got %v", changed)
+	}
+
+	writeFiles(t, cfg.StaticDir, map[string]string{"favicon.ico": "new"})
+	next = history.New()
+	changed, err = diffStaticFiles(previous, &next, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = []string{"favicon.ico"}
+	if !reflect.DeepEqual(changed, expected) {
+		t.Errorf("Expected %v to change, got %v", expected, changed)
+	}
+
+	// Removing static_dir makes every copied file stale
+	cfg.StaticDir = ""
+	next = previous.Clone()
+	_, err = diffStaticFiles(previous, &next, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stale := previous.Stale(next)
+	if len(stale) != 3 {
+		t.Errorf("Expected every static file to be stale, got %v", stale)
+	}
+}
+
+func TestThemeStaticFiles(t *testing.T) {
+	root := t.TempDir()
+	cfg := config.Config{
+		OutputDir: filepath.Join(root, "output"),
+		StaticDir: filepath.Join(root, "static"),
+		Theme:     filepath.Join(root, "theme"),
+	}
+
+	writeFiles(t, filepath.Join(cfg.Theme, "static"), map[string]string{
+		"favicon.ico":      "theme icon",
+		"fonts/theme.woff": "font",
+	})
+	writeFiles(t, cfg.StaticDir, map[string]string{
+		"favicon.ico": "site icon",
+	})
+
+	next := history.New()
+	changed, err := diffStaticFiles(history.New(), &next, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"favicon.ico", filepath.Join("fonts", "theme.woff")}
+	if !reflect.DeepEqual(changed, expected) {
+		t.Errorf("Expected %v to change, got %v", expected, changed)
+	}
+
+	err = copyStaticFiles(changed, cfg, fileWriter(cfg.OutputDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Site files override the theme's ones
+	checkFile(t, filepath.Join(cfg.OutputDir, "favicon.ico"), "site icon")
+	checkFile(t, filepath.Join(cfg.OutputDir, "fonts", "theme.woff"), "font")
+}
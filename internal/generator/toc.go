@@ -0,0 +1,42 @@
+package generator
+
+import (
+	"html"
+	"html/template"
+	"strings"
+
+	"github.com/ByteBakersCo/babilema/internal/parser"
+)
+
+// writeTOCList writes the entries and their children as nested lists.
+func writeTOCList(out *strings.Builder, entries []parser.TOCEntry) {
+	out.WriteString("<ul>\n")
+	for _, entry := range entries {
+		out.WriteString(`<li><a href="#` + html.EscapeString(entry.ID) + `">`)
+		out.WriteString(html.EscapeString(entry.Title) + "</a>")
+		if len(entry.Children) > 0 {
+			out.WriteString("\n")
+			writeTOCList(out, entry.Children)
+		}
+
+		out.WriteString("</li>\n")
+	}
+
+	out.WriteString("</ul>\n")
+}
+
+// renderTOC returns the table of contents of a post as nested lists of
+// links to its headings, in a <nav class="toc"> element. It is empty if the
+// post has no table of contents.
+func renderTOC(entries []parser.TOCEntry) template.HTML {
+	if len(entries) == 0 {
+		return ""
+	}
+
+	var out strings.Builder
+	out.WriteString("<nav class=\"toc\">\n")
+	writeTOCList(&out, entries)
+	out.WriteString("</nav>\n")
+
+	return template.HTML(out.String())
+}
@@ -0,0 +1,35 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/ByteBakersCo/babilema/internal/parser"
+)
+
+func TestRenderTOC(t *testing.T) {
+	toc := renderTOC([]parser.TOCEntry{
+		{Title: "Intro", ID: "intro", Level: 1, Children: []parser.TOCEntry{
+			{Title: "A & B", ID: "a-b", Level: 2},
+		}},
+		{Title: "Usage", ID: "usage", Level: 1},
+	})
+
+	expected := `<nav class="toc">
+<ul>
+<li><a href="#intro">Intro</a>
+<ul>
+<li><a href="#a-b">A &amp; B</a></li>
+</ul>
+</li>
+<li><a href="#usage">Usage</a></li>
+</ul>
+</nav>
+`
+	if string(toc) != expected {
+		t.Errorf("Expected %s, got %s", expected, toc)
+	}
+
+	if toc := renderTOC(nil); toc != "" {
+		t.Errorf("Expected no table of contents, got %s", toc)
+	}
+}
@@ -0,0 +1,246 @@
+// Package highlight colors source code at build time, so that pages do not
+// need a client-side highlighter.
+package highlight
+
+import (
+	"html"
+	"strings"
+)
+
+// tokenKind tells how a token is colored.
+type tokenKind int
+
+const (
+	plain tokenKind = iota
+	comment
+	stringLiteral
+	number
+	keyword
+	typeName
+	literal
+)
+
+// Classes of the <span> tags wrapping the tokens, by kind
+var classes = []string{
+	comment:       "hl-comment",
+	stringLiteral: "hl-string",
+	number:        "hl-number",
+	keyword:       "hl-keyword",
+	typeName:      "hl-type",
+	literal:       "hl-literal",
+}
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' ||
+		c >= 0x80
+}
+
+// wordLength returns the length of the identifier or number s starts with.
+func wordLength(s string) int {
+	i := 0
+	for i < len(s) && (isLetter(s[i]) || isDigit(s[i])) {
+		i++
+	}
+
+	return i
+}
+
+// numberLength returns the length of the number s starts with, such as 42,
+// 0x2A, 4.2 or 4.2e-1.
+func numberLength(s string) int {
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		isExponentSign := (c == '+' || c == '-') && i > 0 &&
+			(s[i-1] == 'e' || s[i-1] == 'E') &&
+			!strings.HasPrefix(strings.ToLower(s), "0x")
+
+		if !isLetter(c) && !isDigit(c) && c != '.' && !isExponentSign {
+			break
+		}
+
+		i++
+	}
+
+	return i
+}
+
+// commentLength returns the length of the comment s starts with, or 0.
+func (lang *language) commentLength(s string) int {
+	for _, prefix := range lang.lineComments {
+		if strings.HasPrefix(s, prefix) {
+			end := strings.IndexByte(s, '\n')
+			if end < 0 {
+				return len(s)
+			}
+
+			return end
+		}
+	}
+
+	for _, delimiters := range lang.blockComments {
+		if !strings.HasPrefix(s, delimiters[0]) {
+			continue
+		}
+
+		start := len(delimiters[0])
+		end := strings.Index(s[start:], delimiters[1])
+		if end < 0 {
+			return len(s)
+		}
+
+		return start + end + len(delimiters[1])
+	}
+
+	return 0
+}
+
+// stringLength returns the length of the string s starts with, or 0.
+// Unterminated strings end with the line (or the code for raw and triple
+// quoted strings).
+func (lang *language) stringLength(s string) int {
+	for _, delimiter := range lang.rawStrings {
+		if !strings.HasPrefix(s, delimiter) {
+			continue
+		}
+
+		start := len(delimiter)
+		end := strings.Index(s[start:], delimiter)
+		if end < 0 {
+			return len(s)
+		}
+
+		return start + end + len(delimiter)
+	}
+
+	for _, delimiter := range lang.strings {
+		if !strings.HasPrefix(s, delimiter) {
+			continue
+		}
+
+		for i := len(delimiter); i < len(s); i++ {
+			switch {
+			case s[i] == '\\':
+				i++
+			case strings.HasPrefix(s[i:], delimiter):
+				return i + len(delimiter)
+			case s[i] == '\n' && len(delimiter) == 1:
+				return i
+			}
+		}
+
+		return len(s)
+	}
+
+	return 0
+}
+
+// classify returns the kind of the word.
+func (lang *language) classify(word string) tokenKind {
+	if lang.caseInsensitive {
+		word = strings.ToUpper(word)
+	}
+
+	switch {
+	case lang.keywords[word]:
+		return keyword
+	case lang.types[word]:
+		return typeName
+	case lang.literals[word]:
+		return literal
+	}
+
+	return plain
+}
+
+// tokenize splits code into tokens. Consecutive tokens of the same kind are
+// merged.
+func (lang *language) tokenize(code string) []token {
+	var tokens []token
+	emit := func(kind tokenKind, text string) {
+		last := len(tokens) - 1
+		if last >= 0 && tokens[last].kind == kind {
+			tokens[last].text += text
+			return
+		}
+
+		tokens = append(tokens, token{kind: kind, text: text})
+	}
+
+	for i := 0; i < len(code); {
+		rest := code[i:]
+		c := rest[0]
+
+		if length := lang.commentLength(rest); length > 0 {
+			emit(comment, rest[:length])
+			i += length
+			continue
+		}
+
+		if length := lang.stringLength(rest); length > 0 {
+			emit(stringLiteral, rest[:length])
+			i += length
+			continue
+		}
+
+		switch {
+		case isDigit(c) || c == '.' && len(rest) > 1 && isDigit(rest[1]):
+			length := numberLength(rest)
+			emit(number, rest[:length])
+			i += length
+
+		case isLetter(c):
+			length := wordLength(rest)
+			emit(lang.classify(rest[:length]), rest[:length])
+			i += length
+
+		// Preprocessor directives and at-rules, e.g. #include or @media
+		case (c == '#' || c == '@') && lang.classify(
+			rest[:1+wordLength(rest[1:])],
+		) == keyword:
+			length := 1 + wordLength(rest[1:])
+			emit(keyword, rest[:length])
+			i += length
+
+		default:
+			emit(plain, rest[:1])
+			i++
+		}
+	}
+
+	return tokens
+}
+
+// Highlight returns the HTML of code written in the given language (or one
+// of its aliases, e.g. "js"), each token wrapped in a <span> whose class
+// tells its kind, e.g. <span class="hl-keyword">func</span>.
+// It returns false if the language is not supported.
+func Highlight(code string, lang string) (string, bool) {
+	language, ok := lookup(lang)
+	if !ok {
+		return "", false
+	}
+
+	var out strings.Builder
+	for _, token := range language.tokenize(code) {
+		if token.kind == plain {
+			out.WriteString(html.EscapeString(token.text))
+			continue
+		}
+
+		out.WriteString(`<span class="` + classes[token.kind] + `">`)
+		out.WriteString(html.EscapeString(token.text))
+		out.WriteString("</span>")
+	}
+
+	return out.String(), true
+}
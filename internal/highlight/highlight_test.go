@@ -0,0 +1,81 @@
+package highlight
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		lang     string
+		code     string
+		expected string
+	}{
+		{
+			lang: "go",
+			code: "func main() { // <start>\n\tx := 0x2A + 1.5e-3\n}",
+			expected: `<span class="hl-keyword">func</span> main() { ` +
+				`<span class="hl-comment">// &lt;start&gt;</span>` + "\n" +
+				"\tx := " + `<span class="hl-number">0x2A</span> + ` +
+				`<span class="hl-number">1.5e-3</span>` + "\n}",
+		},
+		{
+			lang: "py",
+			code: `s = """a "b" \"""" if x is None else 'c'`,
+			expected: `s = <span class="hl-string">` +
+				`&#34;&#34;&#34;a &#34;b&#34; \&#34;&#34;&#34;&#34;</span> ` +
+				`<span class="hl-keyword">if</span> x ` +
+				`<span class="hl-keyword">is</span> ` +
+				`<span class="hl-literal">None</span> ` +
+				`<span class="hl-keyword">else</span> ` +
+				`<span class="hl-string">&#39;c&#39;</span>`,
+		},
+		{
+			lang: "SQL",
+			code: "select id from posts; -- all",
+			expected: `<span class="hl-keyword">select</span> id ` +
+				`<span class="hl-keyword">from</span> posts; ` +
+				`<span class="hl-comment">-- all</span>`,
+		},
+		{
+			lang: "c",
+			code: "#include <stdio.h>",
+			expected: `<span class="hl-keyword">#include</span> ` +
+				`&lt;stdio.h&gt;`,
+		},
+	}
+
+	for _, test := range tests {
+		html, ok := Highlight(test.code, test.lang)
+		if !ok {
+			t.Errorf("%s: expected the language to be supported", test.lang)
+		}
+
+		if html != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.lang, test.expected, html)
+		}
+	}
+
+	if _, ok := Highlight("x", "brainfuck"); ok {
+		t.Error("Expected brainfuck not to be supported")
+	}
+}
+
+func TestCSS(t *testing.T) {
+	for _, name := range Styles() {
+		css, err := CSS(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, class := range classes[comment:] {
+			if !strings.Contains(string(css), "."+class+" {") {
+				t.Errorf("%s: expected a rule for %s", name, class)
+			}
+		}
+	}
+
+	if _, err := CSS("unknown"); err == nil {
+		t.Error("Expected an error for an unknown style")
+	}
+}
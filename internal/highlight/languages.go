@@ -0,0 +1,220 @@
+package highlight
+
+import (
+	"strings"
+
+	"github.com/ByteBakersCo/babilema/internal/utils"
+)
+
+// language describes the syntax of a programming language well enough to
+// tell its comments, strings, numbers and keywords apart.
+type language struct {
+	keywords map[string]bool
+	types    map[string]bool
+	literals map[string]bool
+
+	// Keywords, types and literals are upper case, whatever their case in
+	// the code
+	caseInsensitive bool
+
+	lineComments  []string
+	blockComments [][2]string
+
+	// Delimiters of the strings, longest first. Raw strings do not have
+	// escape sequences and can span several lines.
+	strings    []string
+	rawStrings []string
+}
+
+var cComment = [][2]string{{"/*", "*/"}}
+
+var languages = map[string]*language{
+	"go": {
+		keywords: utils.Words(`break case chan const continue default defer else
+			fallthrough for func go goto if import interface map package
+			range return select struct switch type var`),
+		types: utils.Words(`any bool byte comparable complex64 complex128 error
+			float32 float64 int int8 int16 int32 int64 rune string uint
+			uint8 uint16 uint32 uint64 uintptr`),
+		literals:      utils.Words("true false nil iota"),
+		lineComments:  []string{"//"},
+		blockComments: cComment,
+		strings:       []string{`"`, "'"},
+		rawStrings:    []string{"`"},
+	},
+	"c": {
+		keywords: utils.Words(`auto break case const continue default do else
+			enum extern for goto if inline register restrict return sizeof
+			static struct switch typedef union volatile while #include
+			#define #ifdef #ifndef #endif #if #else #elif #pragma`),
+		types: utils.Words(`char double float int long short signed unsigned
+			void bool size_t int8_t int16_t int32_t int64_t uint8_t uint16_t
+			uint32_t uint64_t`),
+		literals:      utils.Words("true false NULL"),
+		lineComments:  []string{"//"},
+		blockComments: cComment,
+		strings:       []string{`"`, "'"},
+	},
+	"cpp": {
+		keywords: utils.Words(`auto break case catch class const constexpr
+			continue default delete do else enum explicit extern final for
+			friend goto if inline namespace new noexcept operator override
+			private protected public return sizeof static static_cast
+			struct switch template this throw try typedef typename union
+			using virtual volatile while #include #define #ifdef #ifndef
+			#endif #if #else #elif #pragma`),
+		types: utils.Words(`bool char double float int long short signed
+			unsigned void size_t string vector map`),
+		literals:      utils.Words("true false nullptr NULL"),
+		lineComments:  []string{"//"},
+		blockComments: cComment,
+		strings:       []string{`"`, "'"},
+	},
+	"java": {
+		keywords: utils.Words(`abstract assert break case catch class continue
+			default do else enum extends final finally for if implements
+			import instanceof interface native new package private
+			protected public record return static super switch
+			synchronized this throw throws try var void volatile while`),
+		types: utils.Words(`boolean byte char double float int long short String
+			Object`),
+		literals:      utils.Words("true false null"),
+		lineComments:  []string{"//"},
+		blockComments: cComment,
+		strings:       []string{`"""`, `"`, "'"},
+	},
+	"javascript": {
+		keywords: utils.Words(`async await break case catch class const continue
+			debugger default delete do else export extends finally for
+			from function if import in instanceof let new of return
+			static super switch this throw try typeof var void while
+			with yield`),
+		literals:      utils.Words("true false null undefined NaN Infinity"),
+		lineComments:  []string{"//"},
+		blockComments: cComment,
+		strings:       []string{`"`, "'"},
+		rawStrings:    []string{"`"},
+	},
+	"typescript": {
+		keywords: utils.Words(`abstract as async await break case catch class
+			const continue declare default delete do else enum export
+			extends finally for from function if implements import in
+			instanceof interface keyof let namespace new of private
+			protected public readonly return satisfies static super
+			switch this throw try type typeof var void while yield`),
+		types: utils.Words(`any boolean never number object string symbol
+			unknown bigint`),
+		literals:      utils.Words("true false null undefined NaN Infinity"),
+		lineComments:  []string{"//"},
+		blockComments: cComment,
+		strings:       []string{`"`, "'"},
+		rawStrings:    []string{"`"},
+	},
+	"python": {
+		keywords: utils.Words(`and as assert async await break class continue
+			def del elif else except finally for from global if import in is
+			lambda match nonlocal not or pass raise return try while with
+			yield`),
+		types: utils.Words(`bool bytes dict float frozenset int list object set
+			str tuple`),
+		literals:     utils.Words("True False None"),
+		lineComments: []string{"#"},
+		strings:      []string{`"""`, `'''`, `"`, "'"},
+	},
+	"rust": {
+		keywords: utils.Words(`as async await break const continue crate dyn
+			else enum extern fn for if impl in let loop match mod move mut pub
+			ref return self Self static struct super trait type unsafe use
+			where while`),
+		types: utils.Words(`bool char f32 f64 i8 i16 i32 i64 i128 isize str u8
+			u16 u32 u64 u128 usize String Vec Option Result Box`),
+		literals:      utils.Words("true false None Some Ok Err"),
+		lineComments:  []string{"//"},
+		blockComments: cComment,
+		strings:       []string{`"`},
+	},
+	"shell": {
+		keywords: utils.Words(`case do done elif else esac export fi for
+			function if in local return select then until while alias cd echo
+			exit read set shift source test unset`),
+		literals:     utils.Words("true false"),
+		lineComments: []string{"#"},
+		strings:      []string{`"`},
+		rawStrings:   []string{"'"},
+	},
+	"sql": {
+		keywords: utils.Words(`ADD ALL ALTER AND AS ASC BETWEEN BY CASE CHECK
+			COLUMN CONSTRAINT CREATE DEFAULT DELETE DESC DISTINCT DROP ELSE
+			END EXISTS FOREIGN FROM GROUP HAVING IN INDEX INNER INSERT INTO
+			IS JOIN KEY LEFT LIKE LIMIT NOT ON OR ORDER OUTER PRIMARY
+			REFERENCES RIGHT SELECT SET TABLE THEN UNION UNIQUE UPDATE
+			VALUES VIEW WHEN WHERE WITH`),
+		types: utils.Words(`BIGINT BOOLEAN CHAR DATE DECIMAL FLOAT INT INTEGER
+			NUMERIC REAL SERIAL SMALLINT TEXT TIMESTAMP VARCHAR`),
+		literals:        utils.Words("NULL TRUE FALSE"),
+		caseInsensitive: true,
+		lineComments:    []string{"--"},
+		blockComments:   cComment,
+		strings:         []string{"'", `"`},
+	},
+	"json": {
+		literals: utils.Words("true false null"),
+		strings:  []string{`"`},
+	},
+	"yaml": {
+		literals:     utils.Words("true false null yes no on off"),
+		lineComments: []string{"#"},
+		strings:      []string{`"`},
+		rawStrings:   []string{"'"},
+	},
+	"toml": {
+		literals:     utils.Words("true false inf nan"),
+		lineComments: []string{"#"},
+		strings:      []string{`"""`, `"`},
+		rawStrings:   []string{"'''", "'"},
+	},
+	"css": {
+		keywords:      utils.Words("@import @media @keyframes @supports"),
+		literals:      utils.Words("inherit initial none auto important"),
+		blockComments: cComment,
+		strings:       []string{`"`, "'"},
+	},
+}
+
+// Other names of the languages, as used in the info string of fenced code
+// blocks
+var aliases = map[string]string{
+	"golang":     "go",
+	"h":          "c",
+	"c++":        "cpp",
+	"cc":         "cpp",
+	"hpp":        "cpp",
+	"js":         "javascript",
+	"jsx":        "javascript",
+	"mjs":        "javascript",
+	"ts":         "typescript",
+	"tsx":        "typescript",
+	"py":         "python",
+	"python3":    "python",
+	"rs":         "rust",
+	"sh":         "shell",
+	"bash":       "shell",
+	"zsh":        "shell",
+	"console":    "shell",
+	"postgresql": "sql",
+	"mysql":      "sql",
+	"sqlite":     "sql",
+	"yml":        "yaml",
+}
+
+// lookup returns the language called name, if it is supported.
+func lookup(name string) (*language, bool) {
+	name = strings.ToLower(name)
+	if alias, ok := aliases[name]; ok {
+		name = alias
+	}
+
+	lang, ok := languages[name]
+
+	return lang, ok
+}
@@ -0,0 +1,137 @@
+package highlight
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultStyle is used when no style is selected.
+const DefaultStyle string = "github"
+
+// style holds the colors of the code and of each kind of token.
+type style struct {
+	text       string
+	background string
+	colors     map[tokenKind]string
+}
+
+var styles = map[string]style{
+	"github": {
+		text:       "#24292f",
+		background: "#f6f8fa",
+		colors: map[tokenKind]string{
+			comment:       "#6e7781",
+			stringLiteral: "#0a3069",
+			number:        "#0550ae",
+			keyword:       "#cf222e",
+			typeName:      "#953800",
+			literal:       "#0550ae",
+		},
+	},
+	"github-dark": {
+		text:       "#e6edf3",
+		background: "#161b22",
+		colors: map[tokenKind]string{
+			comment:       "#8b949e",
+			stringLiteral: "#a5d6ff",
+			number:        "#79c0ff",
+			keyword:       "#ff7b72",
+			typeName:      "#ffa657",
+			literal:       "#79c0ff",
+		},
+	},
+	"monokai": {
+		text:       "#f8f8f2",
+		background: "#272822",
+		colors: map[tokenKind]string{
+			comment:       "#75715e",
+			stringLiteral: "#e6db74",
+			number:        "#ae81ff",
+			keyword:       "#f92672",
+			typeName:      "#66d9ef",
+			literal:       "#ae81ff",
+		},
+	},
+	"solarized-light": {
+		text:       "#657b83",
+		background: "#fdf6e3",
+		colors: map[tokenKind]string{
+			comment:       "#93a1a1",
+			stringLiteral: "#2aa198",
+			number:        "#d33682",
+			keyword:       "#859900",
+			typeName:      "#b58900",
+			literal:       "#cb4b16",
+		},
+	},
+	"solarized-dark": {
+		text:       "#839496",
+		background: "#002b36",
+		colors: map[tokenKind]string{
+			comment:       "#586e75",
+			stringLiteral: "#2aa198",
+			number:        "#d33682",
+			keyword:       "#859900",
+			typeName:      "#b58900",
+			literal:       "#cb4b16",
+		},
+	},
+}
+
+// Styles returns the names of the available styles, sorted.
+func Styles() []string {
+	var names []string
+	for name := range styles {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// HasStyle tells whether the style called name exists.
+func HasStyle(name string) bool {
+	_, ok := styles[name]
+	return ok
+}
+
+// CSS returns the stylesheet coloring the code highlighted by Highlight,
+// in <pre class="highlight"> blocks.
+func CSS(name string) ([]byte, error) {
+	style, ok := styles[name]
+	if !ok {
+		return nil, fmt.Errorf(
+			"unknown highlight style %q (available: %s)",
+			name,
+			strings.Join(Styles(), ", "),
+		)
+	}
+
+	var css strings.Builder
+	fmt.Fprintf(&css, "/* Syntax highlighting: %s */\n", name)
+	fmt.Fprintf(
+		&css,
+		".highlight,\n.highlight code {\n"+
+			"    color: %s;\n    background: %s;\n}\n",
+		style.text,
+		style.background,
+	)
+
+	for kind, class := range classes {
+		color, ok := style.colors[tokenKind(kind)]
+		if !ok {
+			continue
+		}
+
+		fmt.Fprintf(&css, "\n.highlight .%s {\n    color: %s;\n", class, color)
+		if tokenKind(kind) == comment {
+			css.WriteString("    font-style: italic;\n")
+		}
+
+		css.WriteString("}\n")
+	}
+
+	return []byte(css.String()), nil
+}
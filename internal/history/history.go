@@ -1,10 +1,14 @@
 package history
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"time"
 
 	"github.com/BurntSushi/toml"
@@ -18,21 +22,162 @@ const (
 	warningComment  string = "# This file is auto-generated by Babilema. Do not edit manually.\n\n"
 )
 
+// Post is what Babilema remembers about a generated blog post.
+type Post struct {
+	Slug        string    `toml:"slug"`
+	Hash        string    `toml:"hash"`
+	UpdatedAt   time.Time `toml:"updated_at"`
+	OutputFiles []string  `toml:"output_files"`
+}
+
+// History maps issue numbers to their generated blog post.
 type History struct {
-	Data map[string]time.Time `toml:"history"`
+	Posts map[int]Post
+
+	// Hash of the templates and config used to render the index page
+	IndexHash string
+
+	// Maps the files copied from cfg.StaticDir (relative to cfg.OutputDir)
+	// to the hash of their content.
+	Static map[string]string
+
+	// Files generated along with the pages (e.g. the CSS bundle), relative
+	// to cfg.OutputDir
+	Assets []string
+
+	// Entries of the old slug -> time format, waiting to be matched to an
+	// issue number.
+	legacy map[string]time.Time
+}
+
+// historyFile is the on-disk representation of History (TOML keys have to
+// be strings).
+type historyFile struct {
+	IndexHash string               `toml:"index_hash,omitempty"`
+	Posts     map[string]Post      `toml:"posts"`
+	Static    map[string]string    `toml:"static,omitempty"`
+	Assets    []string             `toml:"assets,omitempty"`
+	Legacy    map[string]time.Time `toml:"history,omitempty"`
+}
+
+func New() History {
+	return History{
+		Posts:  make(map[int]Post),
+		Static: make(map[string]string),
+		legacy: make(map[string]time.Time),
+	}
+}
+
+// Hash returns the hex encoded SHA-256 of the given data.
+func Hash(data ...[]byte) string {
+	hash := sha256.New()
+	for _, d := range data {
+		hash.Write(d)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Lookup returns the post generated from the given issue. Posts recorded
+// with the legacy format are matched by slug.
+func (h History) Lookup(number int, slug string) (Post, bool) {
+	if post, ok := h.Posts[number]; ok {
+		return post, true
+	}
+
+	if updatedAt, ok := h.legacy[slug]; ok {
+		return Post{
+			Slug:        slug,
+			UpdatedAt:   updatedAt,
+			OutputFiles: []string{slug + ".html"},
+		}, true
+	}
+
+	return Post{}, false
 }
 
-func ParseHistoryFile(cfg config.Config) (map[string]time.Time, error) {
-	history := History{
-		Data: make(map[string]time.Time),
+// Set records the post generated from the given issue, migrating any legacy
+// entry with the same slug.
+func (h *History) Set(number int, post Post) {
+	if h.Posts == nil {
+		h.Posts = make(map[int]Post)
 	}
+
+	h.Posts[number] = post
+	delete(h.legacy, post.Slug)
+}
+
+// Clone returns a copy of h that can be modified without altering h.
+func (h History) Clone() History {
+	clone := New()
+	clone.IndexHash = h.IndexHash
+	clone.Assets = append(clone.Assets, h.Assets...)
+	for number, post := range h.Posts {
+		clone.Posts[number] = post
+	}
+
+	for file, hash := range h.Static {
+		clone.Static[file] = hash
+	}
+
+	for slug, updatedAt := range h.legacy {
+		clone.legacy[slug] = updatedAt
+	}
+
+	return clone
+}
+
+// OutputFiles returns every file generated from the recorded posts, along
+// with the static files and assets.
+func (h History) OutputFiles() []string {
+	var files []string
+	for _, post := range h.Posts {
+		files = append(files, post.OutputFiles...)
+	}
+
+	for file := range h.Static {
+		files = append(files, file)
+	}
+
+	files = append(files, h.Assets...)
+
+	for slug := range h.legacy {
+		files = append(files, slug+".html")
+	}
+
+	sort.Strings(files)
+
+	return files
+}
+
+// Stale returns the output files recorded in h that are not part of next,
+// i.e. pages whose source post was closed, deleted or renamed, and static
+// files that were removed.
+func (h History) Stale(next History) []string {
+	kept := make(map[string]bool)
+	for _, file := range next.OutputFiles() {
+		kept[file] = true
+	}
+
+	var stale []string
+	for _, file := range h.OutputFiles() {
+		if !kept[file] {
+			stale = append(stale, file)
+		}
+	}
+
+	return stale
+}
+
+func ParseHistoryFile(cfg config.Config) (History, error) {
+	file := historyFile{}
 	_, err := toml.DecodeFile(
 		filepath.Join(cfg.OutputDir, historyFileName),
-		&history,
+		&file,
 	)
 
 	if err != nil && !errors.Is(err, os.ErrNotExist) {
-		return nil, err
+		return History{}, err
 	}
 
 	if errors.Is(err, os.ErrNotExist) {
@@ -43,18 +188,42 @@ func ParseHistoryFile(cfg config.Config) (map[string]time.Time, error) {
 		log.Println("History file parsed.")
 	}
 
-	return history.Data, nil
+	history := New()
+	history.IndexHash = file.IndexHash
+	history.Assets = file.Assets
+	for key, post := range file.Posts {
+		number, err := strconv.Atoi(key)
+		if err != nil {
+			return History{}, errors.New("invalid issue number: " + key)
+		}
+
+		history.Posts[number] = post
+	}
+
+	for file, hash := range file.Static {
+		history.Static[file] = hash
+	}
+
+	for slug, updatedAt := range file.Legacy {
+		history.legacy[slug] = updatedAt
+	}
+
+	if len(history.legacy) > 0 {
+		log.Println("Legacy history entries found, they will be migrated.")
+	}
+
+	return history, nil
 }
 
-func UpdateHistoryFile(history map[string]time.Time, cfg config.Config) error {
-	file, err := os.OpenFile(
-		filepath.Join(cfg.TempDir, historyFileName),
-		os.O_CREATE|os.O_TRUNC|os.O_WRONLY,
-		0644,
-	)
+// UpdateHistoryFile writes the history file to cfg.OutputDir. The previous
+// file is replaced atomically, so it is left untouched on error.
+func UpdateHistoryFile(history History, cfg config.Config) error {
+	path := filepath.Join(cfg.OutputDir, historyFileName)
+	file, err := os.CreateTemp(filepath.Dir(path), historyFileName+".*")
 	if err != nil {
 		return err
 	}
+	defer os.Remove(file.Name())
 	defer file.Close()
 
 	_, err = file.WriteString(warningComment)
@@ -62,8 +231,34 @@ func UpdateHistoryFile(history map[string]time.Time, cfg config.Config) error {
 		return err
 	}
 
+	data := historyFile{
+		IndexHash: history.IndexHash,
+		Posts:     make(map[string]Post, len(history.Posts)),
+		Static:    history.Static,
+		Assets:    history.Assets,
+		Legacy:    history.legacy,
+	}
+	for number, post := range history.Posts {
+		data.Posts[strconv.Itoa(number)] = post
+	}
+
 	encoder := toml.NewEncoder(file)
-	err = encoder.Encode(History{Data: history})
+	err = encoder.Encode(data)
+	if err != nil {
+		return err
+	}
+
+	err = file.Chmod(0644)
+	if err != nil {
+		return err
+	}
+
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(file.Name(), path)
 	if err != nil {
 		return err
 	}
@@ -72,3 +267,14 @@ func UpdateHistoryFile(history map[string]time.Time, cfg config.Config) error {
 
 	return nil
 }
+
+func RemoveHistoryFile(cfg config.Config) error {
+	err := os.Remove(filepath.Join(cfg.OutputDir, historyFileName))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	log.Println("History file removed.")
+
+	return nil
+}
@@ -2,8 +2,8 @@ package history
 
 import (
 	"log"
-	"maps"
 	"os"
+	"reflect"
 	"testing"
 	"time"
 
@@ -19,9 +19,13 @@ func cleanup() {
 }
 
 func TestUpdateHistoryFile(t *testing.T) {
-	history := map[string]time.Time{
-		"foo": time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC),
-	}
+	history := New()
+	history.Set(42, Post{
+		Slug:        "foo",
+		Hash:        "abc",
+		UpdatedAt:   time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC),
+		OutputFiles: []string{"foo.html"},
+	})
 	err := UpdateHistoryFile(history, config.Config{
 		OutputDir: ".",
 	})
@@ -31,8 +35,12 @@ func TestUpdateHistoryFile(t *testing.T) {
 
 	expected := `# This file is auto-generated by Babilema. Do not edit manually.
 
-[history]
-  foo = 1970-01-01T00:00:00Z
+[posts]
+  [posts.42]
+    slug = "foo"
+    hash = "abc"
+    updated_at = 1970-01-01T00:00:00Z
+    output_files = ["foo.html"]
 `
 
 	content, err := os.ReadFile(historyFileName)
@@ -48,8 +56,13 @@ func TestUpdateHistoryFile(t *testing.T) {
 func TestParseHistoryFile(t *testing.T) {
 	defer cleanup()
 
-	expected := map[string]time.Time{
-		"foo": time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC),
+	expected := map[int]Post{
+		42: {
+			Slug:        "foo",
+			Hash:        "abc",
+			UpdatedAt:   time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC),
+			OutputFiles: []string{"foo.html"},
+		},
 	}
 
 	actual, err := ParseHistoryFile(config.Config{
@@ -59,10 +72,89 @@ func TestParseHistoryFile(t *testing.T) {
 		t.Error(err)
 	}
 
-	if !maps.Equal(expected, actual) {
+	if !reflect.DeepEqual(expected, actual.Posts) {
+		t.Error(
+			utils.FormatStruct(expected, "Expected output to be"),
+			utils.FormatStruct(actual.Posts, "\ngot"),
+		)
+	}
+}
+
+func TestLegacyHistoryMigration(t *testing.T) {
+	defer cleanup()
+
+	legacy := `# This file is auto-generated by Babilema. Do not edit manually.
+
+[history]
+  foo = 1970-01-01T00:00:00Z
+`
+	err := os.WriteFile(historyFileName, []byte(legacy), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.Config{OutputDir: "."}
+	history, err := ParseHistoryFile(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Post{
+		Slug:        "foo",
+		UpdatedAt:   time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC),
+		OutputFiles: []string{"foo.html"},
+	}
+
+	actual, ok := history.Lookup(42, "foo")
+	if !ok {
+		t.Fatal("expected legacy entry to be found by slug")
+	}
+
+	if !reflect.DeepEqual(expected, actual) {
 		t.Error(
 			utils.FormatStruct(expected, "Expected output to be"),
 			utils.FormatStruct(actual, "\ngot"),
 		)
 	}
+
+	history.Set(42, actual)
+	err = UpdateHistoryFile(history, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	history, err = ParseHistoryFile(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := history.Lookup(1, "foo"); ok {
+		t.Error("expected legacy entry to be migrated")
+	}
+
+	if _, ok := history.Lookup(42, ""); !ok {
+		t.Error("expected migrated entry to be keyed by issue number")
+	}
+}
+
+func TestStale(t *testing.T) {
+	previous := New()
+	previous.Set(1, Post{Slug: "foo", OutputFiles: []string{"foo.html"}})
+	previous.Set(2, Post{Slug: "bar", OutputFiles: []string{"bar.html"}})
+	previous.Set(3, Post{Slug: "baz", OutputFiles: []string{"baz.html"}})
+	previous.legacy["qux"] = time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	next := New()
+	next.Set(1, Post{Slug: "foo", OutputFiles: []string{"foo.html"}})
+	next.Set(2, Post{
+		Slug:        "bar-renamed",
+		OutputFiles: []string{"bar-renamed.html"},
+	})
+
+	expected := []string{"bar.html", "baz.html", "qux.html"}
+	actual := previous.Stale(next)
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected %v, got %v", expected, actual)
+	}
 }
@@ -0,0 +1,591 @@
+// Package mathml converts LaTeX formulas to MathML, which browsers render
+// without any script.
+// It supports the commonly used subset of LaTeX math: scripts, fractions,
+// roots, Greek letters, symbols, functions, accents, fonts, \left and \right
+// delimiters and matrix-like environments. Unknown commands are shown as
+// errors in the formula.
+package mathml
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// converter converts a formula, one atom at a time.
+type converter struct {
+	tex     string
+	pos     int
+	display bool
+}
+
+func (c *converter) done() bool {
+	return c.pos >= len(c.tex)
+}
+
+func (c *converter) peek() byte {
+	if c.done() {
+		return 0
+	}
+
+	return c.tex[c.pos]
+}
+
+func (c *converter) skipSpaces() {
+	for !c.done() && unicode.IsSpace(rune(c.peek())) {
+		c.pos++
+	}
+}
+
+// peekCommand tells whether the formula continues with the command \name.
+func (c *converter) peekCommand(name string) bool {
+	rest := c.tex[c.pos:]
+	if !strings.HasPrefix(rest, `\`+name) {
+		return false
+	}
+
+	end := 1 + len(name)
+
+	return end == len(rest) || !isLetter(rest[end]) || !isLetter(name[0])
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// commandName reads the name of the command starting at the backslash: its
+// letters, or the symbol following the backslash.
+func (c *converter) commandName() string {
+	c.pos++
+	start := c.pos
+	for !c.done() && isLetter(c.peek()) {
+		c.pos++
+	}
+
+	if c.pos == start && !c.done() {
+		_, size := utf8.DecodeRuneInString(c.tex[c.pos:])
+		c.pos += size
+	}
+
+	return c.tex[start:c.pos]
+}
+
+// isTerminator tells whether the formula continues with the end of an
+// expression: a closing brace, a column or row separator, \right or \end.
+func (c *converter) isTerminator() bool {
+	switch {
+	case c.done(), c.peek() == '}', c.peek() == '&':
+		return true
+	case strings.HasPrefix(c.tex[c.pos:], `\\`):
+		return true
+	}
+
+	return c.peekCommand("right") || c.peekCommand("end")
+}
+
+// skipTerminator skips a terminator which does not end anything.
+func (c *converter) skipTerminator() {
+	switch {
+	case c.peekCommand("right"):
+		c.commandName()
+		c.delimiter()
+	case c.peekCommand("end"):
+		c.commandName()
+		c.rawGroup()
+	case strings.HasPrefix(c.tex[c.pos:], `\\`):
+		c.pos += 2
+	default:
+		c.pos++
+	}
+}
+
+// expression converts atoms up to the next terminator.
+func (c *converter) expression() string {
+	var out strings.Builder
+	for {
+		c.skipSpaces()
+		if c.isTerminator() {
+			return out.String()
+		}
+
+		out.WriteString(c.scripts(c.atom()))
+	}
+}
+
+// group converts atoms up to the closing brace of the group, whose opening
+// brace was read.
+func (c *converter) group() string {
+	var out strings.Builder
+	for {
+		out.WriteString(c.expression())
+		if c.done() {
+			break
+		}
+
+		if c.peek() == '}' {
+			c.pos++
+			break
+		}
+
+		c.skipTerminator()
+	}
+
+	return "<mrow>" + out.String() + "</mrow>"
+}
+
+// rawGroup returns the text of the group starting at the current position,
+// braces excluded, or the next character if there is no group.
+func (c *converter) rawGroup() string {
+	c.skipSpaces()
+	if c.done() {
+		return ""
+	}
+
+	if c.peek() != '{' {
+		_, size := utf8.DecodeRuneInString(c.tex[c.pos:])
+		c.pos += size
+		return c.tex[c.pos-size : c.pos]
+	}
+
+	start := c.pos + 1
+	depth := 0
+	for ; !c.done(); c.pos++ {
+		switch c.peek() {
+		case '\\':
+			c.pos++
+		case '{':
+			depth++
+		case '}':
+			depth--
+		}
+
+		if depth == 0 {
+			c.pos++
+			return c.tex[start : c.pos-1]
+		}
+	}
+
+	// A trailing backslash moves past the end of the unterminated group
+	c.pos = len(c.tex)
+
+	return c.tex[start:]
+}
+
+// argument converts the argument of a command: a group or a single token.
+func (c *converter) argument() string {
+	c.skipSpaces()
+	switch {
+	case c.done():
+		return "<mrow></mrow>"
+	case c.peek() == '{':
+		c.pos++
+		return c.group()
+	case c.peek() == '\\':
+		mathml, _ := c.command()
+		return mathml
+	}
+
+	r, size := utf8.DecodeRuneInString(c.tex[c.pos:])
+	c.pos += size
+
+	return token(r)
+}
+
+// token converts a single character.
+func token(r rune) string {
+	s := html.EscapeString(string(r))
+	switch {
+	case r >= '0' && r <= '9':
+		return "<mn>" + s + "</mn>"
+	case unicode.IsLetter(r):
+		return "<mi>" + s + "</mi>"
+	case r == '\'':
+		return "<mo>′</mo>"
+	}
+
+	return "<mo>" + s + "</mo>"
+}
+
+// atom converts the next number, letter, symbol, group or command. It also
+// tells whether its scripts are drawn as limits, under and over it.
+func (c *converter) atom() (string, bool) {
+	char := c.peek()
+	switch {
+	case char == '{':
+		c.pos++
+		return c.group(), false
+
+	case char == '\\':
+		return c.command()
+
+	// Scripts without a base, e.g. ^{14}C
+	case char == '^' || char == '_':
+		return "<mrow></mrow>", false
+
+	case isDigit(char) || char == '.' && c.pos+1 < len(c.tex) &&
+		isDigit(c.tex[c.pos+1]):
+		start := c.pos
+		for !c.done() && (isDigit(c.peek()) || c.peek() == '.') {
+			c.pos++
+		}
+
+		return "<mn>" + c.tex[start:c.pos] + "</mn>", false
+	}
+
+	r, size := utf8.DecodeRuneInString(c.tex[c.pos:])
+	c.pos += size
+
+	return token(r), false
+}
+
+// scripts adds the subscript and superscript following the base, if any.
+func (c *converter) scripts(base string, limits bool) string {
+	if base == "" {
+		base = "<mrow></mrow>"
+	}
+
+	var sub, sup string
+	for {
+		c.skipSpaces()
+		switch c.peek() {
+		case '_':
+			c.pos++
+			sub = c.argument()
+			continue
+		case '^':
+			c.pos++
+			sup += c.argument()
+			continue
+		case '\'':
+			c.pos++
+			sup += "<mo>′</mo>"
+			continue
+		}
+
+		if c.peekCommand("limits") || c.peekCommand("nolimits") {
+			limits = c.commandName() == "limits"
+			continue
+		}
+
+		break
+	}
+
+	under, over, both := "msub", "msup", "msubsup"
+	if limits && c.display {
+		under, over, both = "munder", "mover", "munderover"
+	}
+
+	switch {
+	case sub != "" && sup != "":
+		return fmt.Sprintf("<%s>%s%s%s</%s>", both, base, sub, sup, both)
+	case sub != "":
+		return fmt.Sprintf("<%s>%s%s</%s>", under, base, sub, under)
+	case sup != "":
+		return fmt.Sprintf("<%s>%s%s</%s>", over, base, sup, over)
+	}
+
+	return base
+}
+
+// delimiter converts the delimiter following \left or \right, "." meaning
+// none.
+func (c *converter) delimiter() string {
+	c.skipSpaces()
+	if c.done() {
+		return ""
+	}
+
+	var symbol string
+	if c.peek() == '\\' {
+		name := c.commandName()
+		symbol = operators[name]
+		if symbol == "" {
+			symbol = identifiers[name]
+		}
+	} else {
+		r, size := utf8.DecodeRuneInString(c.tex[c.pos:])
+		c.pos += size
+		symbol = string(r)
+	}
+
+	if symbol == "" || symbol == "." {
+		return ""
+	}
+
+	return `<mo fence="true">` + html.EscapeString(symbol) + "</mo>"
+}
+
+// fenced converts the atoms between \left and \right, along with their
+// delimiters.
+func (c *converter) fenced() string {
+	left := c.delimiter()
+
+	var inner strings.Builder
+	for {
+		inner.WriteString(c.expression())
+		if c.done() {
+			return "<mrow>" + left + inner.String() + "</mrow>"
+		}
+
+		if c.peekCommand("right") {
+			c.commandName()
+			break
+		}
+
+		c.skipTerminator()
+	}
+
+	return "<mrow>" + left + inner.String() + c.delimiter() + "</mrow>"
+}
+
+// Delimiters of the matrix-like environments
+var environments = map[string][2]string{
+	"matrix":   {"", ""},
+	"pmatrix":  {"(", ")"},
+	"bmatrix":  {"[", "]"},
+	"Bmatrix":  {"{", "}"},
+	"vmatrix":  {"|", "|"},
+	"Vmatrix":  {"‖", "‖"},
+	"cases":    {"{", ""},
+	"aligned":  {"", ""},
+	"align":    {"", ""},
+	"align*":   {"", ""},
+	"gathered": {"", ""},
+	"array":    {"", ""},
+}
+
+// environment converts the rows of a matrix-like environment to a table.
+func (c *converter) environment(name string) string {
+	if name == "array" {
+		// Skip the column specification
+		c.rawGroup()
+	}
+
+	var rows [][]string
+	var row []string
+	for {
+		row = append(row, c.expression())
+		switch {
+		case c.peek() == '&':
+			c.pos++
+			continue
+		case strings.HasPrefix(c.tex[c.pos:], `\\`):
+			c.pos += 2
+			rows = append(rows, row)
+			row = nil
+			continue
+		case c.peekCommand("end"):
+			c.commandName()
+			c.rawGroup()
+		case !c.done():
+			c.skipTerminator()
+			continue
+		}
+
+		break
+	}
+
+	rows = append(rows, row)
+
+	var table strings.Builder
+	switch name {
+	case "cases":
+		table.WriteString(`<mtable columnalign="left">`)
+	case "aligned", "align", "align*":
+		table.WriteString(`<mtable columnalign="right left">`)
+	default:
+		table.WriteString("<mtable>")
+	}
+
+	for _, row := range rows {
+		table.WriteString("<mtr>")
+		for _, cell := range row {
+			table.WriteString("<mtd>" + cell + "</mtd>")
+		}
+
+		table.WriteString("</mtr>")
+	}
+
+	table.WriteString("</mtable>")
+
+	delimiters := environments[name]
+	out := table.String()
+	if delimiters[0] != "" {
+		out = `<mo fence="true">` + delimiters[0] + "</mo>" + out
+	}
+
+	if delimiters[1] != "" {
+		out += `<mo fence="true">` + delimiters[1] + "</mo>"
+	}
+
+	return "<mrow>" + out + "</mrow>"
+}
+
+// styled converts the letters and digits of text with font, or converts it
+// as is if it contains anything else.
+func (c *converter) styled(text string, font func(rune) rune) string {
+	var out strings.Builder
+	for _, r := range text {
+		switch {
+		case r >= '0' && r <= '9':
+			out.WriteString("<mn>" + string(font(r)) + "</mn>")
+		case r < unicode.MaxASCII && unicode.IsLetter(r):
+			out.WriteString("<mi>" + string(font(r)) + "</mi>")
+		case unicode.IsSpace(r):
+		default:
+			inner := &converter{tex: text, display: c.display}
+			return inner.formula()
+		}
+	}
+
+	return "<mrow>" + out.String() + "</mrow>"
+}
+
+// command converts the command starting at the backslash.
+func (c *converter) command() (string, bool) {
+	name := c.commandName()
+
+	if symbol, ok := greek[name]; ok {
+		if unicode.IsUpper([]rune(symbol)[0]) {
+			return `<mi mathvariant="normal">` + symbol + "</mi>", false
+		}
+
+		return "<mi>" + symbol + "</mi>", false
+	}
+
+	if symbol, ok := identifiers[name]; ok {
+		return "<mi>" + symbol + "</mi>", false
+	}
+
+	if symbol, ok := operators[name]; ok {
+		return "<mo>" + html.EscapeString(symbol) + "</mo>", false
+	}
+
+	if symbol, ok := largeOperators[name]; ok {
+		return "<mo>" + symbol + "</mo>", true
+	}
+
+	if limits, ok := functions[name]; ok {
+		return "<mi>" + name + "</mi>", limits
+	}
+
+	if accent, ok := accents[name]; ok {
+		return fmt.Sprintf(
+			`<mover accent="true">%s<mo>%s</mo></mover>`,
+			c.argument(),
+			html.EscapeString(accent),
+		), false
+	}
+
+	if accent, ok := underAccents[name]; ok {
+		return fmt.Sprintf(
+			`<munder accentunder="true">%s<mo>%s</mo></munder>`,
+			c.argument(),
+			accent,
+		), false
+	}
+
+	if width, ok := spaces[name]; ok {
+		return `<mspace width="` + width + `"></mspace>`, false
+	}
+
+	if font, ok := fonts[name]; ok {
+		return c.styled(c.rawGroup(), font), false
+	}
+
+	if ignored[name] {
+		return "", false
+	}
+
+	switch name {
+	case "frac", "dfrac", "tfrac":
+		return "<mfrac>" + c.argument() + c.argument() + "</mfrac>", false
+
+	case "binom":
+		return `<mrow><mo fence="true">(</mo><mfrac linethickness="0">` +
+			c.argument() + c.argument() +
+			`</mfrac><mo fence="true">)</mo></mrow>`, false
+
+	case "sqrt":
+		c.skipSpaces()
+		if c.peek() != '[' {
+			return "<msqrt>" + c.argument() + "</msqrt>", false
+		}
+
+		end := strings.IndexByte(c.tex[c.pos:], ']')
+		if end < 0 {
+			return "<msqrt>" + c.argument() + "</msqrt>", false
+		}
+
+		index := &converter{tex: c.tex[c.pos+1 : c.pos+end]}
+		c.pos += end + 1
+
+		return "<mroot>" + c.argument() + index.formula() + "</mroot>", false
+
+	case "text", "textrm", "textit", "textbf", "mbox":
+		return "<mtext>" + html.EscapeString(c.rawGroup()) + "</mtext>", false
+
+	case "mathrm", "operatorname", "mathsf", "mathtt":
+		return `<mi mathvariant="normal">` +
+			html.EscapeString(c.rawGroup()) + "</mi>", false
+
+	case "mathit":
+		return "<mi>" + html.EscapeString(c.rawGroup()) + "</mi>", false
+
+	case "left":
+		return c.fenced(), false
+
+	case "begin":
+		env := c.rawGroup()
+		if _, ok := environments[env]; ok {
+			return c.environment(env), false
+		}
+
+		return merror(`\begin{` + env + "}"), false
+	}
+
+	return merror(`\` + name), false
+}
+
+// merror shows the unsupported LaTeX tex as an error.
+func merror(tex string) string {
+	return "<merror><mtext>" + html.EscapeString(tex) + "</mtext></merror>"
+}
+
+// formula converts the whole formula.
+func (c *converter) formula() string {
+	var out strings.Builder
+	for {
+		out.WriteString(c.expression())
+		if c.done() {
+			break
+		}
+
+		c.skipTerminator()
+	}
+
+	return "<mrow>" + out.String() + "</mrow>"
+}
+
+// Convert returns the MathML of the LaTeX formula tex, shown as a block if
+// display is set or inline with the text otherwise. The LaTeX is kept as an
+// annotation, so that the formula can be copied.
+func Convert(tex string, display bool) string {
+	c := &converter{tex: tex, display: display}
+
+	attrs := ""
+	if display {
+		attrs = ` display="block"`
+	}
+
+	return "<math" + attrs + "><semantics>" + c.formula() +
+		`<annotation encoding="application/x-tex">` +
+		html.EscapeString(strings.TrimSpace(tex)) +
+		"</annotation></semantics></math>"
+}
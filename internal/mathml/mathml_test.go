@@ -0,0 +1,117 @@
+package mathml
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		tex      string
+		display  bool
+		expected string
+	}{
+		{
+			tex: `x^2 + y_i^{n+1}`,
+			expected: `<msup><mi>x</mi><mn>2</mn></msup><mo>+</mo>` +
+				`<msubsup><mi>y</mi><mi>i</mi>` +
+				`<mrow><mi>n</mi><mo>+</mo><mn>1</mn></mrow></msubsup>`,
+		},
+		{
+			tex: `\frac{a}{b} \leq \sqrt[3]{x}`,
+			expected: `<mfrac><mrow><mi>a</mi></mrow><mrow><mi>b</mi></mrow>` +
+				`</mfrac><mo>≤</mo><mroot><mrow><mi>x</mi></mrow>` +
+				`<mrow><mn>3</mn></mrow></mroot>`,
+		},
+		{
+			// Limits are drawn under and over large operators in blocks
+			tex:     `\sum_{i=1}^n \alpha_i`,
+			display: true,
+			expected: `<munderover><mo>∑</mo>` +
+				`<mrow><mi>i</mi><mo>=</mo><mn>1</mn></mrow><mi>n</mi>` +
+				`</munderover><msub><mi>α</mi><mi>i</mi></msub>`,
+		},
+		{
+			tex: `\sum_{i=1}^n`,
+			expected: `<msubsup><mo>∑</mo>` +
+				`<mrow><mi>i</mi><mo>=</mo><mn>1</mn></mrow><mi>n</mi>` +
+				`</msubsup>`,
+		},
+		{
+			tex: `\left( \mathbb{R} \right] \text{if } a < b`,
+			expected: `<mrow><mo fence="true">(</mo>` +
+				`<mrow><mi>ℝ</mi></mrow><mo fence="true">]</mo></mrow>` +
+				`<mtext>if </mtext><mi>a</mi><mo>&lt;</mo><mi>b</mi>`,
+		},
+		{
+			tex: `\begin{pmatrix} 1 & 2 \\ 3 & 4 \end{pmatrix}`,
+			expected: `<mrow><mo fence="true">(</mo><mtable>` +
+				`<mtr><mtd><mn>1</mn></mtd><mtd><mn>2</mn></mtd></mtr>` +
+				`<mtr><mtd><mn>3</mn></mtd><mtd><mn>4</mn></mtd></mtr>` +
+				`</mtable><mo fence="true">)</mo></mrow>`,
+		},
+		{
+			// Unknown commands and unbalanced braces
+			tex: `f'(x) \foo }`,
+			expected: `<msup><mi>f</mi><mo>′</mo></msup>` +
+				`<mo>(</mo><mi>x</mi><mo>)</mo>` +
+				`<merror><mtext>\foo</mtext></merror>`,
+		},
+	}
+
+	for _, test := range tests {
+		mathml := Convert(test.tex, test.display)
+
+		prefix := "<math><semantics><mrow>"
+		if test.display {
+			prefix = `<math display="block"><semantics><mrow>`
+		}
+
+		body, ok := strings.CutPrefix(mathml, prefix)
+		body, _, _ = strings.Cut(body, "</mrow><annotation")
+		if !ok || body != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.tex, test.expected, mathml)
+		}
+
+		annotation := `<annotation encoding="application/x-tex">`
+		if !strings.Contains(mathml, annotation) {
+			t.Errorf("%s: expected the LaTeX annotation, got %s", test.tex, mathml)
+		}
+	}
+}
+
+func TestConvertUnterminated(t *testing.T) {
+	for _, tex := range []string{
+		`\begin{\`,
+		`\text{a \`,
+		`\mathrm{x\`,
+		`\begin{pmatrix} 1 & \`,
+		`\sqrt[3`,
+		`\left(`,
+		`x^`,
+		`\`,
+	} {
+		mathml := Convert(tex, false)
+		if !strings.HasPrefix(mathml, "<math>") ||
+			!strings.HasSuffix(mathml, "</math>") {
+			t.Errorf("%s: expected a formula, got %s", tex, mathml)
+		}
+	}
+}
+
+func FuzzConvert(f *testing.F) {
+	f.Add(`x^2 + y_i^{n+1}`, false)
+	f.Add(`\frac{a}{b} \leq \sqrt[3]{x}`, true)
+	f.Add(`\begin{pmatrix} 1 & 2 \\ 3 & 4 \end{pmatrix}`, true)
+	f.Add(`\left( \mathbb{R} \right] \text{if } a < b`, false)
+	f.Add(`\begin{\`, false)
+
+	// Bad formulas are shown as errors, they never fail
+	f.Fuzz(func(t *testing.T, tex string, display bool) {
+		mathml := Convert(tex, display)
+		if !strings.HasPrefix(mathml, "<math") ||
+			!strings.HasSuffix(mathml, "</math>") {
+			t.Errorf("%q: expected a formula, got %s", tex, mathml)
+		}
+	})
+}
@@ -0,0 +1,142 @@
+package mathml
+
+// Greek letters, rendered as identifiers
+var greek = map[string]string{
+	"alpha": "α", "beta": "β", "gamma": "γ", "delta": "δ",
+	"epsilon": "ϵ", "varepsilon": "ε", "zeta": "ζ", "eta": "η",
+	"theta": "θ", "vartheta": "ϑ", "iota": "ι", "kappa": "κ",
+	"lambda": "λ", "mu": "μ", "nu": "ν", "xi": "ξ", "pi": "π",
+	"varpi": "ϖ", "rho": "ρ", "varrho": "ϱ", "sigma": "σ",
+	"varsigma": "ς", "tau": "τ", "upsilon": "υ", "phi": "ϕ",
+	"varphi": "φ", "chi": "χ", "psi": "ψ", "omega": "ω",
+	"Gamma": "Γ", "Delta": "Δ", "Theta": "Θ", "Lambda": "Λ", "Xi": "Ξ",
+	"Pi": "Π", "Sigma": "Σ", "Upsilon": "Υ", "Phi": "Φ", "Psi": "Ψ",
+	"Omega": "Ω",
+}
+
+// Symbols rendered as identifiers
+var identifiers = map[string]string{
+	"infty": "∞", "emptyset": "∅", "varnothing": "∅", "partial": "∂",
+	"nabla": "∇", "ell": "ℓ", "hbar": "ℏ", "aleph": "ℵ", "Re": "ℜ",
+	"Im": "ℑ", "top": "⊤", "bot": "⊥", "angle": "∠", "triangle": "△",
+	"ldots": "…", "cdots": "⋯", "vdots": "⋮", "ddots": "⋱",
+	"dots": "…", "prime": "′",
+}
+
+// Symbols rendered as operators
+var operators = map[string]string{
+	"times": "×", "div": "÷", "cdot": "⋅", "pm": "±", "mp": "∓",
+	"ast": "∗", "star": "⋆", "circ": "∘", "bullet": "∙", "oplus": "⊕",
+	"otimes": "⊗", "wedge": "∧", "land": "∧", "vee": "∨", "lor": "∨",
+	"neg": "¬", "lnot": "¬", "setminus": "∖", "cup": "∪", "cap": "∩",
+	"leq": "≤", "le": "≤", "geq": "≥", "ge": "≥", "neq": "≠",
+	"ne": "≠", "approx": "≈", "equiv": "≡", "sim": "∼", "simeq": "≃",
+	"cong":   "≅",
+	"propto": "∝", "ll": "≪", "gg": "≫", "prec": "≺", "succ": "≻",
+	"in": "∈", "notin": "∉", "ni": "∋", "subset": "⊂", "supset": "⊃",
+	"subseteq": "⊆", "supseteq": "⊇", "forall": "∀", "exists": "∃",
+	"mid": "∣", "parallel": "∥", "perp": "⊥", "vdash": "⊢",
+	"models": "⊨", "to": "→", "rightarrow": "→", "leftarrow": "←",
+	"gets": "←", "leftrightarrow": "↔", "Rightarrow": "⇒",
+	"Leftarrow": "⇐", "Leftrightarrow": "⇔", "implies": "⟹",
+	"iff": "⟺", "mapsto": "↦", "uparrow": "↑", "downarrow": "↓",
+	"langle": "⟨", "rangle": "⟩", "lfloor": "⌊", "rfloor": "⌋",
+	"lceil": "⌈", "rceil": "⌉", "vert": "|", "lvert": "|", "rvert": "|",
+	"Vert": "‖", "lVert": "‖", "rVert": "‖", "|": "‖", "{": "{",
+	"}": "}", "colon": ":", "%": "%", "$": "$", "#": "#", "&": "&",
+	"_": "_",
+}
+
+// Large operators, whose scripts are limits in display mode
+var largeOperators = map[string]string{
+	"sum": "∑", "prod": "∏", "coprod": "∐", "int": "∫", "iint": "∬",
+	"iiint": "∭", "oint": "∮", "bigcup": "⋃", "bigcap": "⋂",
+	"bigoplus": "⨁", "bigotimes": "⨂", "bigvee": "⋁", "bigwedge": "⋀",
+}
+
+// Functions, rendered upright. Those marked true have limits in display
+// mode.
+var functions = map[string]bool{
+	"sin": false, "cos": false, "tan": false, "cot": false, "sec": false,
+	"csc": false, "arcsin": false, "arccos": false, "arctan": false,
+	"sinh": false, "cosh": false, "tanh": false, "log": false, "ln": false,
+	"lg": false, "exp": false, "deg": false, "dim": false, "ker": false,
+	"hom": false, "arg": false, "det": true, "gcd": true, "max": true,
+	"min": true, "sup": true, "inf": true, "lim": true, "liminf": true,
+	"limsup": true, "Pr": true,
+}
+
+// Accents, drawn over (or under) their argument
+var accents = map[string]string{
+	"hat": "^", "widehat": "^", "bar": "¯", "overline": "‾", "vec": "→",
+	"overrightarrow": "→", "tilde": "~", "widetilde": "~", "dot": "˙",
+	"ddot": "¨", "check": "ˇ", "breve": "˘", "acute": "´", "grave": "`",
+}
+
+var underAccents = map[string]string{
+	"underline": "_",
+}
+
+// Horizontal spaces, in em
+var spaces = map[string]string{
+	",": "0.167em", ":": "0.222em", ">": "0.222em", ";": "0.278em",
+	" ": "0.25em", "quad": "1em", "qquad": "2em", "enspace": "0.5em",
+}
+
+// Commands changing the font of letters (mathrm and friends are handled
+// separately since their letters are upright)
+var fonts = map[string]func(rune) rune{
+	"mathbb":     doubleStruck,
+	"mathbf":     bold,
+	"boldsymbol": bold,
+	"mathcal":    script,
+}
+
+// Commands without any visible effect in MathML
+var ignored = map[string]bool{
+	"displaystyle": true, "textstyle": true, "limits": true,
+	"nolimits": true, "!": true, "big": true, "Big": true, "bigg": true,
+	"Bigg": true, "bigl": true, "bigr": true, "Bigl": true, "Bigr": true,
+}
+
+// offset maps a letter or digit to the Unicode mathematical alphanumeric
+// symbols starting at upper (A), lower (a) and digit (0), with exceptions
+// for the symbols defined elsewhere in Unicode.
+func offset(
+	r rune,
+	upper, lower, digit rune,
+	exceptions map[rune]rune,
+) rune {
+	if exception, ok := exceptions[r]; ok {
+		return exception
+	}
+
+	switch {
+	case r >= 'A' && r <= 'Z' && upper != 0:
+		return upper + r - 'A'
+	case r >= 'a' && r <= 'z' && lower != 0:
+		return lower + r - 'a'
+	case r >= '0' && r <= '9' && digit != 0:
+		return digit + r - '0'
+	}
+
+	return r
+}
+
+func doubleStruck(r rune) rune {
+	return offset(r, 0x1D538, 0x1D552, 0x1D7D8, map[rune]rune{
+		'C': 'ℂ', 'H': 'ℍ', 'N': 'ℕ', 'P': 'ℙ', 'Q': 'ℚ', 'R': 'ℝ',
+		'Z': 'ℤ',
+	})
+}
+
+func bold(r rune) rune {
+	return offset(r, 0x1D400, 0x1D41A, 0x1D7CE, nil)
+}
+
+func script(r rune) rune {
+	return offset(r, 0x1D49C, 0, 0, map[rune]rune{
+		'B': 'ℬ', 'E': 'ℰ', 'F': 'ℱ', 'H': 'ℋ', 'I': 'ℐ', 'L': 'ℒ',
+		'M': 'ℳ', 'R': 'ℛ',
+	})
+}
@@ -0,0 +1,342 @@
+package parser
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/google/go-github/github"
+)
+
+// gfmExtensions are the GitHub Flavored Markdown features gomarkdown does
+// not support.
+type gfmExtensions int
+
+const (
+	// - [ ] and - [x] list items
+	taskLists gfmExtensions = 1 << iota
+
+	// > [!NOTE], > [!TIP], > [!IMPORTANT], > [!WARNING] and > [!CAUTION]
+	alerts
+
+	// @user links to the user's GitHub profile
+	mentions
+
+	// #123 links to the issue of the repository
+	issueRefs
+
+	// ```mermaid code blocks are diagrams
+	mermaid
+
+	commonGFMExtensions = taskLists | alerts | mentions | issueRefs | mermaid
+)
+
+// Names of the GitHub Flavored Markdown extensions, as used in
+// cfg.MarkdownExtensions
+var gfmExtensionNames = map[string]gfmExtensions{
+	"task_lists": taskLists,
+	"alerts":     alerts,
+	"mentions":   mentions,
+	"issue_refs": issueRefs,
+	"mermaid":    mermaid,
+}
+
+var alertTitles = map[string]string{
+	"NOTE":      "Note",
+	"TIP":       "Tip",
+	"IMPORTANT": "Important",
+	"WARNING":   "Warning",
+	"CAUTION":   "Caution",
+}
+
+var (
+	alertMarker = regexp.MustCompile(`^\[!([A-Z]+)\][ \t]*(\n|$)`)
+	taskMarker  = regexp.MustCompile(`^\[([ xX])\][ \t]+`)
+
+	// GitHub usernames are made of alphanumeric characters and single
+	// hyphens. References must not be part of a word, an email address or
+	// an HTML entity.
+	reference = regexp.MustCompile(
+		`(^|[^\w@#&/])(@[A-Za-z0-9](?:-?[A-Za-z0-9])*|#[0-9]+)\b`,
+	)
+)
+
+// repositoryURL returns the URL of the repository the issue belongs to, or
+// of GITHUB_REPOSITORY for local posts.
+func repositoryURL(issue github.Issue) string {
+	repoURL, _, ok := strings.Cut(issue.GetHTMLURL(), "/issues/")
+	if ok {
+		return repoURL
+	}
+
+	if repo := os.Getenv("GITHUB_REPOSITORY"); repo != "" {
+		return "https://github.com/" + repo
+	}
+
+	return ""
+}
+
+// gfm renders the GitHub Flavored Markdown features of a document.
+type gfm struct {
+	extensions gfmExtensions
+
+	// Links to issues and users are relative to repoURL's host
+	repoURL string
+
+	alerts map[*ast.BlockQuote]string
+
+	// Set once a Mermaid diagram is rendered
+	hasMermaid bool
+}
+
+func newGFM(extensions gfmExtensions, repoURL string) *gfm {
+	return &gfm{
+		extensions: extensions,
+		repoURL:    repoURL,
+		alerts:     make(map[*ast.BlockQuote]string),
+	}
+}
+
+// firstText returns the text the paragraph starts with, if any.
+func firstText(node ast.Node) (*ast.Text, bool) {
+	paragraph, ok := ast.GetFirstChild(node).(*ast.Paragraph)
+	if !ok {
+		return nil, false
+	}
+
+	text, ok := ast.GetFirstChild(paragraph).(*ast.Text)
+
+	return text, ok
+}
+
+// insertBefore adds nodes to the children of node's parent, before node.
+func insertBefore(node ast.Node, nodes ...ast.Node) {
+	parent := node.GetParent()
+
+	var children []ast.Node
+	for _, child := range parent.GetChildren() {
+		if child == node {
+			children = append(children, nodes...)
+		}
+
+		children = append(children, child)
+	}
+
+	for _, child := range nodes {
+		child.SetParent(parent)
+	}
+
+	parent.SetChildren(children)
+}
+
+// isLinked tells whether the node is already part of a link.
+func isLinked(node ast.Node) bool {
+	parent := node.GetParent()
+	for ; parent != nil; parent = parent.GetParent() {
+		switch parent.(type) {
+		case *ast.Link, *ast.Image:
+			return true
+		}
+	}
+
+	return false
+}
+
+func (g *gfm) taskList(listItem *ast.ListItem) {
+	text, ok := firstText(listItem)
+	if !ok {
+		return
+	}
+
+	marker := taskMarker.FindSubmatch(text.Literal)
+	if marker == nil {
+		return
+	}
+
+	checkbox := `<input type="checkbox" disabled> `
+	if string(marker[1]) != " " {
+		checkbox = `<input type="checkbox" disabled checked> `
+	}
+
+	text.Literal = text.Literal[len(marker[0]):]
+	insertBefore(
+		text,
+		&ast.HTMLSpan{Leaf: ast.Leaf{Literal: []byte(checkbox)}},
+	)
+}
+
+func (g *gfm) alert(blockQuote *ast.BlockQuote) {
+	text, ok := firstText(blockQuote)
+	if !ok {
+		return
+	}
+
+	marker := alertMarker.FindSubmatch(text.Literal)
+	if marker == nil || alertTitles[string(marker[1])] == "" {
+		return
+	}
+
+	text.Literal = text.Literal[len(marker[0]):]
+	paragraph := text.GetParent()
+	if len(text.Literal) == 0 && len(paragraph.GetChildren()) == 1 {
+		ast.RemoveFromTree(paragraph)
+	}
+
+	g.alerts[blockQuote] = string(marker[1])
+}
+
+// referenceURL returns the URL of a @mention or #123 reference.
+func (g *gfm) referenceURL(ref string) string {
+	if name, ok := strings.CutPrefix(ref, "@"); ok {
+		if g.extensions&mentions == 0 {
+			return ""
+		}
+
+		host := "https://github.com"
+		repoURL, err := url.Parse(g.repoURL)
+		if err == nil && g.repoURL != "" {
+			host = repoURL.Scheme + "://" + repoURL.Host
+		}
+
+		return host + "/" + name
+	}
+
+	if g.extensions&issueRefs == 0 || g.repoURL == "" {
+		return ""
+	}
+
+	return g.repoURL + "/issues/" + strings.TrimPrefix(ref, "#")
+}
+
+func (g *gfm) references(text *ast.Text) {
+	if isLinked(text) {
+		return
+	}
+
+	var nodes []ast.Node
+	literal := text.Literal
+	for {
+		match := reference.FindSubmatchIndex(literal)
+		if match == nil {
+			break
+		}
+
+		// match[4:6] is the reference, match[2:4] what comes before it
+		ref := string(literal[match[4]:match[5]])
+		refURL := g.referenceURL(ref)
+		if refURL == "" {
+			nodes = append(nodes, &ast.Text{
+				Leaf: ast.Leaf{Literal: literal[:match[5]]},
+			})
+			literal = literal[match[5]:]
+			continue
+		}
+
+		link := &ast.Link{Destination: []byte(refURL)}
+		ast.AppendChild(
+			link,
+			&ast.Text{Leaf: ast.Leaf{Literal: []byte(ref)}},
+		)
+		nodes = append(
+			nodes,
+			&ast.Text{Leaf: ast.Leaf{Literal: literal[:match[4]]}},
+			link,
+		)
+		literal = literal[match[5]:]
+	}
+
+	if nodes == nil {
+		return
+	}
+
+	insertBefore(text, nodes...)
+	text.Literal = literal
+}
+
+// transform rewrites the document's nodes using GitHub Flavored Markdown
+// syntax.
+func (g *gfm) transform(doc ast.Node) {
+	var texts []*ast.Text
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.GoToNext
+		}
+
+		switch node := node.(type) {
+		case *ast.ListItem:
+			if g.extensions&taskLists != 0 {
+				g.taskList(node)
+			}
+		case *ast.BlockQuote:
+			if g.extensions&alerts != 0 {
+				g.alert(node)
+			}
+		case *ast.Text:
+			texts = append(texts, node)
+		}
+
+		return ast.GoToNext
+	})
+
+	// Texts are split once the walk is over, so that it does not visit the
+	// new nodes
+	if g.extensions&(mentions|issueRefs) != 0 {
+		for _, text := range texts {
+			g.references(text)
+		}
+	}
+}
+
+// isMermaid tells whether the code block is a Mermaid diagram.
+func isMermaid(codeBlock *ast.CodeBlock) bool {
+	info := strings.Fields(string(codeBlock.Info))
+	return len(info) > 0 && info[0] == "mermaid"
+}
+
+// renderHook renders alerts and Mermaid diagrams the way GitHub does.
+// Diagrams are drawn by the Mermaid script from <pre class="mermaid"> blocks.
+func (g *gfm) renderHook(
+	w io.Writer,
+	node ast.Node,
+	entering bool,
+) (ast.WalkStatus, bool) {
+	codeBlock, ok := node.(*ast.CodeBlock)
+	if ok && g.extensions&mermaid != 0 && isMermaid(codeBlock) {
+		g.hasMermaid = true
+		io.WriteString(w, `<pre class="mermaid">`)
+		io.WriteString(w, html.EscapeString(string(codeBlock.Literal)))
+		io.WriteString(w, "</pre>\n")
+
+		return ast.GoToNext, true
+	}
+
+	blockQuote, ok := node.(*ast.BlockQuote)
+	if !ok {
+		return ast.GoToNext, false
+	}
+
+	kind, ok := g.alerts[blockQuote]
+	if !ok {
+		return ast.GoToNext, false
+	}
+
+	if !entering {
+		io.WriteString(w, "</div>\n")
+		return ast.GoToNext, true
+	}
+
+	fmt.Fprintf(
+		w,
+		"<div class=\"markdown-alert markdown-alert-%s\">\n"+
+			"<p class=\"markdown-alert-title\">%s</p>\n",
+		strings.ToLower(kind),
+		alertTitles[kind],
+	)
+
+	return ast.GoToNext, true
+}
@@ -0,0 +1,76 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/ByteBakersCo/babilema/internal/config"
+)
+
+func TestRenderGFM(t *testing.T) {
+	content := []byte(`> [!WARNING]
+> Ask @octo-cat about #12, not foo@example.com or ` + "`#13`" + `.
+
+- [ ] Todo
+- [x] Done
+
+See [#14](https://example.com).
+`)
+
+	expected := `<div class="markdown-alert markdown-alert-warning">
+<p class="markdown-alert-title">Warning</p>
+<p>Ask <a href="https://github.com/octo-cat">@octo-cat</a> about ` +
+		`<a href="https://github.com/owner/repo/issues/12">#12</a>, ` +
+		`not foo@example.com or <code>#13</code>.</p>
+</div>
+
+<ul>
+<li><input type="checkbox" disabled> Todo</li>
+<li><input type="checkbox" disabled checked> Done</li>
+</ul>
+
+<p>See <a href="https://example.com">#14</a>.</p>
+`
+
+	rendered, err := renderMarkdown(
+		content,
+		"https://github.com/owner/repo",
+		config.Config{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(rendered.html) != expected {
+		t.Errorf("Expected %s, got %s", expected, rendered.html)
+	}
+
+	// Without a repository, issue references are left as is
+	rendered, err = renderMarkdown(
+		[]byte("#12 by @octocat"),
+		"",
+		config.Config{MarkdownExtensions: []string{"-mentions"}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = "<p>#12 by @octocat</p>\n"
+	if string(rendered.html) != expected {
+		t.Errorf("Expected %q, got %q", expected, rendered.html)
+	}
+
+	// Mermaid diagrams are left to the Mermaid script
+	rendered, err = renderMarkdown(
+		[]byte("```mermaid\ngraph TD\n  A --> B\n```\n"),
+		"",
+		config.Config{SyntaxHighlighting: true},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = "<pre class=\"mermaid\">graph TD\n  A --&gt; B\n</pre>\n"
+	if string(rendered.html) != expected || !rendered.mermaid {
+		t.Errorf("Expected a Mermaid diagram %q, got %q", expected, rendered.html)
+	}
+}
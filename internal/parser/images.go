@@ -0,0 +1,174 @@
+package parser
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/net/html"
+
+	"github.com/ByteBakersCo/babilema/internal/config"
+	"github.com/ByteBakersCo/babilema/internal/history"
+	"github.com/ByteBakersCo/babilema/internal/utils"
+)
+
+// ImagesDir is where self-hosted images are stored, relative to
+// cfg.OutputDir.
+const ImagesDir string = "assets/images"
+
+// Asset is a file published along with a blog post.
+type Asset struct {
+	// Relative to cfg.OutputDir
+	Path    string
+	Content []byte
+}
+
+// MaxImageSize is the size above which images are not self-hosted.
+const MaxImageSize int64 = 20 << 20
+
+// Fetcher downloads the file at the given URL.
+type Fetcher func(url string) ([]byte, error)
+
+// DefaultHTTPClient is the client downloading the images to self-host, so
+// that an unresponsive host cannot block the build.
+var DefaultHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// HTTPFetcher returns a Fetcher downloading files of up to MaxImageSize
+// bytes with client.
+func HTTPFetcher(client *http.Client) Fetcher {
+	return func(url string) ([]byte, error) {
+		resp, err := client.Get(url)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status %s", resp.Status)
+		}
+
+		content, err := io.ReadAll(io.LimitReader(resp.Body, MaxImageSize+1))
+		if err != nil {
+			return nil, err
+		}
+
+		if int64(len(content)) > MaxImageSize {
+			return nil, fmt.Errorf("larger than %d bytes", MaxImageSize)
+		}
+
+		return content, nil
+	}
+}
+
+var imageExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+	"image/bmp":  ".bmp",
+}
+
+// imagePath returns the path of the self-hosted copy of the image at src.
+// Images are named after their URL, so that an image used by several posts
+// is only stored once. Their extension comes from their content, which must
+// be one of imageExtensions: the URL could make us publish pages or scripts
+// on the website.
+func imagePath(src *url.URL, content []byte) (string, error) {
+	contentType := http.DetectContentType(content)
+	ext, ok := imageExtensions[contentType]
+	if !ok {
+		return "", fmt.Errorf("unsupported content type %s", contentType)
+	}
+
+	name := history.Hash([]byte(src.String()))[:16] + ext
+
+	return filepath.Join(filepath.FromSlash(ImagesDir), name), nil
+}
+
+// isRemote tells whether src is an image hosted outside of the website.
+func isRemote(src *url.URL, cfg config.Config) bool {
+	if src.Scheme != "http" && src.Scheme != "https" {
+		return false
+	}
+
+	websiteURL, err := url.Parse(cfg.WebsiteURL)
+
+	return err != nil || src.Host != websiteURL.Host
+}
+
+// selfHostImages downloads the remote images of the post with fetch and
+// points their <img> tags to the copies stored in ImagesDir. Images that
+// cannot be downloaded are left as is.
+func selfHostImages(
+	parsedIssue *ParsedIssue,
+	cfg config.Config,
+	fetch Fetcher,
+) {
+	downloaded := make(map[string]string)
+
+	content := utils.RewriteTags(
+		[]byte(parsedIssue.Content),
+		func(token *html.Token) bool {
+			isImage := token.Data == "img" &&
+				(token.Type == html.StartTagToken ||
+					token.Type == html.SelfClosingTagToken)
+
+			if !isImage {
+				return false
+			}
+
+			for i, attr := range token.Attr {
+				if attr.Key != "src" {
+					continue
+				}
+
+				if imagePath, ok := downloaded[attr.Val]; ok {
+					token.Attr[i].Val = filepath.ToSlash(imagePath)
+					continue
+				}
+
+				src, err := url.Parse(attr.Val)
+				if err != nil || !isRemote(src, cfg) {
+					continue
+				}
+
+				content, err := fetch(attr.Val)
+				if err != nil {
+					log.Printf(
+						"Could not download image %s: %s\n",
+						attr.Val,
+						err,
+					)
+					continue
+				}
+
+				imagePath, err := imagePath(src, content)
+				if err != nil {
+					log.Printf(
+						"Could not self-host image %s: %s\n",
+						attr.Val,
+						err,
+					)
+					continue
+				}
+
+				parsedIssue.Assets = append(parsedIssue.Assets, Asset{
+					Path:    imagePath,
+					Content: content,
+				})
+
+				downloaded[attr.Val] = imagePath
+				token.Attr[i].Val = filepath.ToSlash(imagePath)
+			}
+
+			return true
+		},
+	)
+
+	parsedIssue.Content = template.HTML(content)
+}
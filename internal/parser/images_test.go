@@ -0,0 +1,104 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ByteBakersCo/babilema/internal/config"
+)
+
+func TestSelfHostImages(t *testing.T) {
+	cfg := config.Config{WebsiteURL: "https://blog.example.com"}
+	parsedIssue := ParsedIssue{
+		Content: template.HTML(`<p>
+<img src="https://example.com/cat.png" alt="Cat">
+<img src="https://github.com/user-attachments/assets/1234" alt="Dog">
+<img src="https://example.com/cat.png" alt="Cat again">
+<img src="https://blog.example.com/local.png" alt="Local">
+<img src="relative.png" alt="Relative">
+<img src="https://example.com/missing.png" alt="Missing">
+<img src="https://example.com/page.html" alt="Page">
+<img src="https://example.com/fake.png" alt="Fake"></p>`),
+	}
+
+	fetched := make(map[string]int)
+	fetch := func(url string) ([]byte, error) {
+		fetched[url]++
+		switch url {
+		case "https://example.com/cat.png":
+			return []byte("\x89PNG\r\n\x1a\n cat"), nil
+		case "https://github.com/user-attachments/assets/1234":
+			return []byte("\xff\xd8\xff\xe0 dog"), nil
+		case "https://example.com/page.html":
+			return []byte("<html><script>alert(1)</script>"), nil
+		case "https://example.com/fake.png":
+			return []byte("<svg onload=alert(1)>"), nil
+		}
+
+		return nil, errors.New("not found")
+	}
+
+	selfHostImages(&parsedIssue, cfg, fetch)
+
+	if len(fetched) != 5 || fetched["https://example.com/cat.png"] != 1 {
+		t.Errorf("Expected remote images to be fetched once, got %v", fetched)
+	}
+
+	if len(parsedIssue.Assets) != 2 {
+		t.Fatalf("Expected 2 assets, got %d", len(parsedIssue.Assets))
+	}
+
+	cat, dog := parsedIssue.Assets[0], parsedIssue.Assets[1]
+	if filepath.Ext(cat.Path) != ".png" || filepath.Ext(dog.Path) != ".jpg" {
+		t.Errorf("Unexpected asset paths %s and %s", cat.Path, dog.Path)
+	}
+
+	content := string(parsedIssue.Content)
+	expected := []string{
+		`<img src="` + filepath.ToSlash(cat.Path) + `" alt="Cat">`,
+		`<img src="` + filepath.ToSlash(dog.Path) + `" alt="Dog">`,
+		`<img src="` + filepath.ToSlash(cat.Path) + `" alt="Cat again">`,
+		`<img src="https://blog.example.com/local.png" alt="Local">`,
+		`<img src="relative.png" alt="Relative">`,
+		`<img src="https://example.com/missing.png" alt="Missing">`,
+		`<img src="https://example.com/page.html" alt="Page">`,
+		`<img src="https://example.com/fake.png" alt="Fake">`,
+	}
+	for _, s := range expected {
+		if !strings.Contains(content, s) {
+			t.Errorf("Expected content to contain %q, got %s", s, content)
+		}
+	}
+}
+
+func TestHTTPFetcher(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			size := 10
+			if r.URL.Path == "/large.png" {
+				size = int(MaxImageSize) + 1
+			}
+
+			w.Write(bytes.Repeat([]byte("x"), size))
+		},
+	))
+	defer server.Close()
+
+	fetch := HTTPFetcher(server.Client())
+
+	content, err := fetch(server.URL + "/small.png")
+	if err != nil || len(content) != 10 {
+		t.Errorf("Expected 10 bytes, got %d (%v)", len(content), err)
+	}
+
+	_, err = fetch(server.URL + "/large.png")
+	if err == nil {
+		t.Errorf("Expected images larger than %d bytes to fail", MaxImageSize)
+	}
+}
@@ -0,0 +1,149 @@
+package parser
+
+import (
+	"fmt"
+	"html/template"
+	"net/url"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"github.com/ByteBakersCo/babilema/internal/config"
+	"github.com/ByteBakersCo/babilema/internal/utils"
+)
+
+// postURLs returns the URL of the generated page of each post, by issue
+// number.
+func postURLs(
+	parsedIssues []ParsedIssue,
+	cfg config.Config,
+) (map[int]string, error) {
+	websiteURL, err := url.Parse(cfg.WebsiteURL)
+	if err != nil {
+		return nil, err
+	}
+
+	urls := make(map[int]string)
+	for _, issue := range parsedIssues {
+		relativePath, err := utils.RelativeFilePath(
+			filepath.Join(cfg.OutputDir, issue.Metadata.Slug+".html"),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		postURL := *websiteURL
+		postURL.Path = path.Join(
+			websiteURL.Path,
+			filepath.ToSlash(relativePath),
+		)
+		postURL.RawQuery = ""
+		postURL.Fragment = ""
+		urls[issue.Number] = postURL.String()
+	}
+
+	return urls, nil
+}
+
+// linkedIssue returns the number of the issue of the repository at repoURL
+// that href links to. Relative links are resolved against issueURL, the
+// issue they are part of, the way GitHub does.
+func linkedIssue(href string, issueURL string, repoURL string) (int, bool) {
+	// Links within the page, e.g. to a heading or a footnote
+	if href == "" || strings.HasPrefix(href, "#") {
+		return 0, false
+	}
+
+	base, err := url.Parse(issueURL)
+	if err != nil {
+		return 0, false
+	}
+
+	target, err := base.Parse(href)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") {
+		return 0, false
+	}
+
+	repo, err := url.Parse(repoURL)
+	if err != nil || repoURL == "" ||
+		!strings.EqualFold(target.Host, repo.Host) {
+		return 0, false
+	}
+
+	// Owners and repositories names are case insensitive
+	prefix := strings.ToLower(
+		strings.TrimSuffix(repo.Path, "/") + "/issues/",
+	)
+	targetPath := strings.TrimSuffix(target.Path, "/")
+	if !strings.HasPrefix(strings.ToLower(targetPath), prefix) {
+		return 0, false
+	}
+
+	number, err := strconv.Atoi(targetPath[len(prefix):])
+
+	return number, err == nil
+}
+
+// rewritePostLinks points the links of the post to other blog posts (#42
+// references, issue URLs and relative links to issues) to their generated
+// page, so that readers stay on the blog. It returns the numbers of the
+// issues the post links to, blog posts or not.
+func rewritePostLinks(
+	parsedIssue *ParsedIssue,
+	issueURL string,
+	repoURL string,
+	urls map[int]string,
+) []int {
+	var linked []int
+
+	content := utils.RewriteTags(
+		[]byte(parsedIssue.Content),
+		func(token *html.Token) bool {
+			if token.Data != "a" || token.Type != html.StartTagToken {
+				return false
+			}
+
+			rewritten := false
+			for i, attr := range token.Attr {
+				if attr.Key != "href" {
+					continue
+				}
+
+				number, ok := linkedIssue(attr.Val, issueURL, repoURL)
+				if !ok {
+					continue
+				}
+
+				linked = append(linked, number)
+				if postURL, isPost := urls[number]; isPost {
+					token.Attr[i].Val = postURL
+					rewritten = true
+				}
+			}
+
+			return rewritten
+		},
+	)
+
+	parsedIssue.Content = template.HTML(content)
+
+	slices.Sort(linked)
+
+	return slices.Compact(linked)
+}
+
+// linkedURLs lists the issues a post links to along with the URL of their
+// page, if they are blog posts. It is part of the hash of the post, so that
+// it is generated again when a post it links to is renamed or removed.
+func linkedURLs(linked []int, urls map[int]string) string {
+	var list strings.Builder
+	for _, number := range linked {
+		fmt.Fprintf(&list, "%d %s\n", number, urls[number])
+	}
+
+	return list.String()
+}
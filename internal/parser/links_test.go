@@ -0,0 +1,87 @@
+package parser
+
+import (
+	"html/template"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ByteBakersCo/babilema/internal/config"
+	"github.com/ByteBakersCo/babilema/internal/utils"
+)
+
+func TestRewritePostLinks(t *testing.T) {
+	rootDir, err := utils.RootDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.Config{
+		WebsiteURL: "https://example.com/blog",
+		OutputDir:  filepath.Join(rootDir, "output"),
+	}
+
+	urls, err := postURLs([]ParsedIssue{
+		{Number: 12, Metadata: Metadata{Slug: "first-post"}},
+		{Number: 14, Metadata: Metadata{Slug: "second-post"}},
+	}, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsedIssue := ParsedIssue{
+		Content: template.HTML(`<p>` +
+			`<a href="https://github.com/owner/repo/issues/12">#12</a> ` +
+			`<a href="https://github.com/Owner/Repo/issues/14/#top">Next</a> ` +
+			`<a href="12">Relative</a> ` +
+			`<a href="../issues/14" title="Up">Up</a> ` +
+			`<a href="https://github.com/owner/repo/issues/13">#13</a> ` +
+			`<a href="https://github.com/other/repo/issues/12">Other</a> ` +
+			`<a href="https://github.com/owner/repo/pull/12">PR</a> ` +
+			`<a href="#fn:1">1</a></p>`),
+	}
+
+	linked := rewritePostLinks(
+		&parsedIssue,
+		"https://github.com/owner/repo/issues/15",
+		"https://github.com/owner/repo",
+		urls,
+	)
+
+	first := "https://example.com/blog/output/first-post.html"
+	second := "https://example.com/blog/output/second-post.html"
+	expected := `<p>` +
+		`<a href="` + first + `">#12</a> ` +
+		`<a href="` + second + `">Next</a> ` +
+		`<a href="` + first + `">Relative</a> ` +
+		`<a href="` + second + `" title="Up">Up</a> ` +
+		`<a href="https://github.com/owner/repo/issues/13">#13</a> ` +
+		`<a href="https://github.com/other/repo/issues/12">Other</a> ` +
+		`<a href="https://github.com/owner/repo/pull/12">PR</a> ` +
+		`<a href="#fn:1">1</a></p>`
+	if string(parsedIssue.Content) != expected {
+		t.Errorf("Expected %s, got %s", expected, parsedIssue.Content)
+	}
+
+	if !reflect.DeepEqual(linked, []int{12, 13, 14}) {
+		t.Errorf("Expected links to issues 12, 13 and 14, got %v", linked)
+	}
+
+	// Renaming a linked post changes the hash of the posts linking to it
+	renamed, err := postURLs([]ParsedIssue{
+		{Number: 12, Metadata: Metadata{Slug: "renamed-post"}},
+		{Number: 14, Metadata: Metadata{Slug: "second-post"}},
+	}, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if linkedURLs(linked, urls) == linkedURLs(linked, renamed) ||
+		linkedURLs([]int{14}, urls) != linkedURLs([]int{14}, renamed) {
+		t.Errorf("Expected only links to renamed posts to change")
+	}
+
+	if linkedURLs(nil, urls) != "" {
+		t.Errorf("Expected no links to be listed")
+	}
+}
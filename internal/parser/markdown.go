@@ -0,0 +1,266 @@
+package parser
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/ast"
+	mdhtml "github.com/gomarkdown/markdown/html"
+	mdparser "github.com/gomarkdown/markdown/parser"
+
+	"github.com/ByteBakersCo/babilema/internal/config"
+	"github.com/ByteBakersCo/babilema/internal/highlight"
+	"github.com/ByteBakersCo/babilema/internal/mathml"
+)
+
+// Names of the gomarkdown parser extensions, as used in
+// cfg.MarkdownExtensions
+var markdownExtensions = map[string]mdparser.Extensions{
+	"no_intra_emphasis":          mdparser.NoIntraEmphasis,
+	"tables":                     mdparser.Tables,
+	"fenced_code":                mdparser.FencedCode,
+	"autolink":                   mdparser.Autolink,
+	"strikethrough":              mdparser.Strikethrough,
+	"lax_html_blocks":            mdparser.LaxHTMLBlocks,
+	"space_headings":             mdparser.SpaceHeadings,
+	"hard_line_break":            mdparser.HardLineBreak,
+	"non_blocking_space":         mdparser.NonBlockingSpace,
+	"tab_size_eight":             mdparser.TabSizeEight,
+	"footnotes":                  mdparser.Footnotes,
+	"no_empty_line_before_block": mdparser.NoEmptyLineBeforeBlock,
+	"heading_ids":                mdparser.HeadingIDs,
+	"titleblock":                 mdparser.Titleblock,
+	"auto_heading_ids":           mdparser.AutoHeadingIDs,
+	"backslash_line_break":       mdparser.BackslashLineBreak,
+	"definition_lists":           mdparser.DefinitionLists,
+	"mathjax":                    mdparser.MathJax,
+	"ordered_list_start":         mdparser.OrderedListStart,
+	"attributes":                 mdparser.Attributes,
+	"super_subscript":            mdparser.SuperSubscript,
+	"empty_lines_break_list":     mdparser.EmptyLinesBreakList,
+}
+
+// Names of the gomarkdown HTML renderer flags, as used in
+// cfg.MarkdownHTMLFlags
+var markdownHTMLFlags = map[string]mdhtml.Flags{
+	"skip_html":                 mdhtml.SkipHTML,
+	"skip_images":               mdhtml.SkipImages,
+	"skip_links":                mdhtml.SkipLinks,
+	"safelink":                  mdhtml.Safelink,
+	"nofollow_links":            mdhtml.NofollowLinks,
+	"noreferrer_links":          mdhtml.NoreferrerLinks,
+	"noopener_links":            mdhtml.NoopenerLinks,
+	"href_target_blank":         mdhtml.HrefTargetBlank,
+	"use_xhtml":                 mdhtml.UseXHTML,
+	"footnote_return_links":     mdhtml.FootnoteReturnLinks,
+	"footnote_no_hr_tag":        mdhtml.FootnoteNoHRTag,
+	"smartypants":               mdhtml.Smartypants,
+	"smartypants_fractions":     mdhtml.SmartypantsFractions,
+	"smartypants_dashes":        mdhtml.SmartypantsDashes,
+	"smartypants_latex_dashes":  mdhtml.SmartypantsLatexDashes,
+	"smartypants_angled_quotes": mdhtml.SmartypantsAngledQuotes,
+	"smartypants_quotes_nbsp":   mdhtml.SmartypantsQuotesNBSP,
+	"lazy_load_images":          mdhtml.LazyLoadImages,
+}
+
+// applyFlags enables the flags named in names on top of defaults, or
+// disables them when their name starts with "-".
+func applyFlags[T ~int](
+	defaults T,
+	names []string,
+	known map[string]T,
+	kind string,
+) (T, error) {
+	flags := defaults
+	for _, name := range names {
+		name, disable := strings.CutPrefix(strings.TrimSpace(name), "-")
+
+		flag, ok := known[name]
+		if !ok {
+			return 0, fmt.Errorf("unknown Markdown %s %q", kind, name)
+		}
+
+		if disable {
+			flags &^= flag
+		} else {
+			flags |= flag
+		}
+	}
+
+	return flags, nil
+}
+
+// splitExtensions separates the names of the GitHub Flavored Markdown
+// extensions from the gomarkdown ones.
+func splitExtensions(names []string) ([]string, []string) {
+	var gfmNames, mdNames []string
+	for _, name := range names {
+		trimmed := strings.TrimPrefix(strings.TrimSpace(name), "-")
+		if _, ok := gfmExtensionNames[trimmed]; ok {
+			gfmNames = append(gfmNames, name)
+		} else {
+			mdNames = append(mdNames, name)
+		}
+	}
+
+	return gfmNames, mdNames
+}
+
+// chainHooks returns a render hook calling hooks in order, until one of them
+// renders the node.
+func chainHooks(hooks ...mdhtml.RenderNodeFunc) mdhtml.RenderNodeFunc {
+	return func(
+		w io.Writer,
+		node ast.Node,
+		entering bool,
+	) (ast.WalkStatus, bool) {
+		for _, hook := range hooks {
+			status, rendered := hook(w, node, entering)
+			if rendered {
+				return status, true
+			}
+		}
+
+		return ast.GoToNext, false
+	}
+}
+
+// highlightHook renders the code blocks written in a language supported by
+// the highlight package with syntax highlighting.
+func highlightHook(
+	w io.Writer,
+	node ast.Node,
+	entering bool,
+) (ast.WalkStatus, bool) {
+	codeBlock, ok := node.(*ast.CodeBlock)
+	if !ok {
+		return ast.GoToNext, false
+	}
+
+	info := strings.Fields(string(codeBlock.Info))
+	if len(info) == 0 {
+		return ast.GoToNext, false
+	}
+
+	code, ok := highlight.Highlight(string(codeBlock.Literal), info[0])
+	if !ok {
+		return ast.GoToNext, false
+	}
+
+	fmt.Fprintf(
+		w,
+		`<pre class="highlight"><code class="language-%s">%s</code></pre>`+
+			"\n",
+		html.EscapeString(info[0]),
+		code,
+	)
+
+	return ast.GoToNext, true
+}
+
+// mathHook renders the $inline$ and $$block$$ formulas as MathML.
+func mathHook(
+	w io.Writer,
+	node ast.Node,
+	entering bool,
+) (ast.WalkStatus, bool) {
+	switch node := node.(type) {
+	case *ast.Math:
+		io.WriteString(w, mathml.Convert(string(node.Literal), false))
+	case *ast.MathBlock:
+		if entering {
+			io.WriteString(w, mathml.Convert(string(node.Literal), true)+"\n")
+		}
+	default:
+		return ast.GoToNext, false
+	}
+
+	return ast.GoToNext, true
+}
+
+// defaultExtensions are the gomarkdown parser extensions enabled unless
+// disabled in cfg.MarkdownExtensions.
+const defaultExtensions = mdparser.CommonExtensions | mdparser.AutoHeadingIDs
+
+// renderedMarkdown is a Markdown document converted to HTML, along with what
+// was found while rendering it.
+type renderedMarkdown struct {
+	html []byte
+	toc  []TOCEntry
+
+	// Whether the document has Mermaid diagrams
+	mermaid bool
+}
+
+// renderMarkdown converts content to HTML with the extensions and renderer
+// flags set in cfg, on top of the default ones and the GitHub Flavored
+// Markdown ones. Issue references link to repoURL. Code blocks are
+// highlighted if cfg.SyntaxHighlighting is set, and formulas converted to
+// MathML if cfg.Math is set.
+func renderMarkdown(
+	content []byte,
+	repoURL string,
+	cfg config.Config,
+) (renderedMarkdown, error) {
+	gfmNames, mdNames := splitExtensions(cfg.MarkdownExtensions)
+
+	extensions, err := applyFlags(
+		defaultExtensions,
+		mdNames,
+		markdownExtensions,
+		"extension",
+	)
+	if err != nil {
+		return renderedMarkdown{}, err
+	}
+
+	gfmExtensions, err := applyFlags(
+		commonGFMExtensions,
+		gfmNames,
+		gfmExtensionNames,
+		"extension",
+	)
+	if err != nil {
+		return renderedMarkdown{}, err
+	}
+
+	flags, err := applyFlags(
+		mdhtml.CommonFlags,
+		cfg.MarkdownHTMLFlags,
+		markdownHTMLFlags,
+		"HTML flag",
+	)
+	if err != nil {
+		return renderedMarkdown{}, err
+	}
+
+	doc := markdown.Parse(content, mdparser.NewWithExtensions(extensions))
+
+	gfm := newGFM(gfmExtensions, repoURL)
+	gfm.transform(doc)
+
+	toc := tableOfContents(doc)
+
+	hooks := []mdhtml.RenderNodeFunc{gfm.renderHook}
+	if cfg.SyntaxHighlighting {
+		hooks = append(hooks, highlightHook)
+	}
+
+	if cfg.Math {
+		hooks = append(hooks, mathHook)
+	}
+
+	renderer := mdhtml.NewRenderer(mdhtml.RendererOptions{
+		Flags:          flags,
+		RenderNodeHook: chainHooks(hooks...),
+	})
+
+	return renderedMarkdown{
+		html:    markdown.Render(doc, renderer),
+		toc:     toc,
+		mermaid: gfm.hasMermaid,
+	}, nil
+}
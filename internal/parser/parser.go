@@ -7,11 +7,12 @@ import (
 	"html/template"
 	"log"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/BurntSushi/toml"
-	"github.com/gomarkdown/markdown"
 	"github.com/google/go-github/github"
 	"golang.org/x/oauth2"
 
@@ -30,6 +31,9 @@ type Metadata struct {
 	Publisher   string
 	Tags        []string
 
+	// Whether to show the table of contents of the post (toc = true)
+	TOC bool
+
 	// Determined at runtime (WebsiteURL + Slug)
 	URL string
 
@@ -41,6 +45,20 @@ type Metadata struct {
 type ParsedIssue struct {
 	Content  template.HTML
 	Metadata Metadata
+	Number   int
+	Hash     string
+
+	// True when the post was already generated and did not change since
+	IsUpToDate bool
+
+	// Files to publish along with the post (e.g. self-hosted images)
+	Assets []Asset
+
+	// The headings of the post, if Metadata.TOC is set
+	TableOfContents []TOCEntry
+
+	// True when the post has Mermaid diagrams, which need the Mermaid script
+	HasMermaid bool
 }
 
 func trimAllSpaces(array []string) []string {
@@ -154,10 +172,199 @@ func extractMarkdown(content []byte) ([]byte, error) {
 	return []byte(strings.Join(lines[endOfHeader:], "\n")), nil
 }
 
-func ParseIssues(cfg config.Config) ([]ParsedIssue, error) {
+// NewPostBody returns the body of a new blog post (issue or local Markdown
+// file) with its front matter filled in.
+func NewPostBody(title string, slug string) string {
+	return fmt.Sprintf(`---
+title = %q
+slug = %q
+description = ""
+keywords = []
+author = ""
+image = ""
+tags = []
+---
+
+Write your blog post here.
+`, title, slug)
+}
+
+// parseIssue converts the issue's front matter and Markdown body.
+func parseIssue(issue github.Issue, cfg config.Config) (ParsedIssue, error) {
+	metadata, err := extractMetadata(issue, cfg)
+	if err != nil {
+		return ParsedIssue{}, err
+	}
+
+	content, err := extractMarkdown([]byte(issue.GetBody()))
+	if err != nil {
+		return ParsedIssue{}, err
+	}
+
+	rendered, err := renderMarkdown(content, repositoryURL(issue), cfg)
+	if err != nil {
+		return ParsedIssue{}, err
+	}
+
+	if !metadata.TOC {
+		rendered.toc = nil
+	}
+
+	// Collaborators' HTML is not trusted more than their Markdown
+	body := sanitizeHTML(rendered.html, cfg)
+
+	return ParsedIssue{
+		Content:         template.HTML(body),
+		Metadata:        metadata,
+		Number:          issue.GetNumber(),
+		TableOfContents: rendered.toc,
+		HasMermaid:      rendered.mermaid,
+	}, nil
+}
+
+// ParseLocalPosts returns the blog posts written as Markdown files (with the
+// same front matter as issues) in cfg.PostsDir. They are used to preview a
+// blog locally and are never up to date.
+func ParseLocalPosts(cfg config.Config) ([]ParsedIssue, error) {
+	if cfg.PostsDir == "" {
+		return nil, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(cfg.PostsDir, "*.md"))
+	if err != nil {
+		return nil, err
+	}
+
+	var parsedIssues []ParsedIssue
+	for i, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		body := string(content)
+		number := i + 1
+		modTime := info.ModTime()
+		parsedIssue, err := parseIssue(github.Issue{
+			Number:    &number,
+			Body:      &body,
+			CreatedAt: &modTime,
+			UpdatedAt: &modTime,
+		}, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(file), err)
+		}
+
+		parsedIssues = append(parsedIssues, parsedIssue)
+	}
+
+	log.Printf("Found %d local blog posts.\n", len(parsedIssues))
+
+	return parsedIssues, nil
+}
+
+func listIssues(
+	ctx context.Context,
+	client *github.Client,
+	owner string,
+	repo string,
+) ([]*github.Issue, error) {
+	opts := &github.IssueListByRepoOptions{
+		State:       "open",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	var issues []*github.Issue
+	for {
+		page, resp, err := client.Issues.ListByRepo(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		issues = append(issues, page...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opts.Page = resp.NextPage
+	}
+
+	return issues, nil
+}
+
+// Options changes which posts ParseIssues flags as outdated.
+type Options struct {
+	// Regenerate every post regardless of the history file
+	Force bool
+
+	// Only regenerate the posts matching these slugs or issue numbers
+	// (e.g. "my-post", "42" or "#42")
+	Only []string
+
+	// Downloads the images to self-host (when cfg.SelfHostImages is set),
+	// defaults to HTTPFetcher(DefaultHTTPClient)
+	Fetch Fetcher
+}
+
+// matches tells whether ref is the slug or the issue number of a post.
+func matches(ref string, number int, slug string) bool {
+	ref = strings.TrimPrefix(strings.TrimSpace(ref), "#")
+
+	return ref == slug || ref == strconv.Itoa(number)
+}
+
+func (opts Options) selects(number int, slug string) bool {
+	if len(opts.Only) == 0 {
+		return true
+	}
+
+	for _, ref := range opts.Only {
+		if matches(ref, number, slug) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// unmatched returns the refs of opts.Only that match none of the posts.
+func (opts Options) unmatched(posts []ParsedIssue) []string {
+	var refs []string
+	for _, ref := range opts.Only {
+		found := false
+		for _, post := range posts {
+			if matches(ref, post.Number, post.Metadata.Slug) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			refs = append(refs, ref)
+		}
+	}
+
+	return refs
+}
+
+// ParseIssues returns every blog post found in the repository's open issues
+// along with the history to record once they are generated.
+// Posts that did not change since they were last generated (according to
+// postsHistory) are flagged with IsUpToDate.
+func ParseIssues(
+	cfg config.Config,
+	postsHistory history.History,
+	opts Options,
+) ([]ParsedIssue, history.History, error) {
 	ghToken := os.Getenv("GITHUB_TOKEN")
 	if ghToken == "" {
-		return nil, errors.New("GITHUB_TOKEN not set")
+		return nil, history.History{}, errors.New("GITHUB_TOKEN not set")
 	}
 
 	ctx := context.Background()
@@ -167,23 +374,38 @@ func ParseIssues(cfg config.Config) ([]ParsedIssue, error) {
 
 	repo := os.Getenv("GITHUB_REPOSITORY")
 	if repo == "" {
-		return nil, errors.New("GITHUB_REPOSITORY not set")
+		return nil, history.History{}, errors.New("GITHUB_REPOSITORY not set")
 	}
 
 	parts := strings.Split(repo, "/")
 	owner, repo := parts[0], parts[1]
 
-	issues, _, err := client.Issues.ListByRepo(ctx, owner, repo, nil)
+	issues, err := listIssues(ctx, client, owner, repo)
 	if err != nil {
-		return nil, err
+		return nil, history.History{}, err
 	}
 
-	postsHistory, err := history.ParseHistoryFile(cfg)
+	fingerprint, err := config.PostFingerprint(cfg)
 	if err != nil {
-		return nil, err
+		return nil, history.History{}, err
+	}
+
+	fetch := opts.Fetch
+	if fetch == nil {
+		fetch = HTTPFetcher(DefaultHTTPClient)
 	}
 
+	// A partial build leaves the posts that were not selected untouched
+	next := history.New()
+	if len(opts.Only) > 0 {
+		next = postsHistory.Clone()
+	}
+
+	// The issues of the posts, which are only checked once every post is
+	// known since they depend on the posts they link to.
+	var postIssues []*github.Issue
 	var parsedIssues []ParsedIssue
+	var allPosts []ParsedIssue
 	for _, issue := range issues {
 		if !strings.HasPrefix(issue.GetTitle(), cfg.BlogPostIssuePrefix) {
 			continue
@@ -196,7 +418,7 @@ func ParseIssues(cfg config.Config) ([]ParsedIssue, error) {
 			issue.GetUser().GetLogin(),
 		)
 		if err != nil {
-			return nil, err
+			return nil, history.History{}, err
 		}
 
 		hasWritePermission := permissionLevel.GetPermission() == "write" ||
@@ -206,46 +428,101 @@ func ParseIssues(cfg config.Config) ([]ParsedIssue, error) {
 			continue
 		}
 
-		metadata, err := extractMetadata(*issue, cfg)
+		parsedIssue, err := parseIssue(*issue, cfg)
 		if err != nil {
-			return nil, err
+			return nil, history.History{}, err
 		}
 
-		if _, ok := postsHistory[metadata.Slug]; ok {
-			isUpdated := issue.GetUpdatedAt().After(postsHistory[metadata.Slug])
-			if !isUpdated {
-				continue
-			}
-		}
+		allPosts = append(allPosts, parsedIssue)
 
-		postsHistory[metadata.Slug] = issue.GetUpdatedAt()
-
-		content, err := extractMarkdown([]byte(issue.GetBody()))
-		if err != nil {
-			return nil, err
+		// Only keep the posts that were not selected whose current page is
+		// still valid, the others will be generated on the next full build.
+		slug := parsedIssue.Metadata.Slug
+		previous, ok := postsHistory.Lookup(issue.GetNumber(), slug)
+		if !opts.selects(issue.GetNumber(), slug) &&
+			(!ok || previous.Slug != slug) {
+			continue
 		}
 
-		content = markdown.ToHTML(content, nil, nil)
+		postIssues = append(postIssues, issue)
+		parsedIssues = append(parsedIssues, parsedIssue)
+	}
 
-		parsedIssues = append(parsedIssues, ParsedIssue{
-			Content:  template.HTML(content),
-			Metadata: metadata,
-		})
+	if refs := opts.unmatched(allPosts); len(refs) > 0 {
+		return nil, history.History{}, fmt.Errorf(
+			"no blog post matches %s",
+			strings.Join(refs, ", "),
+		)
 	}
 
-	log.Printf("Found %d blog posts to generate.\n", len(parsedIssues))
+	urls, err := postURLs(parsedIssues, cfg)
+	if err != nil {
+		return nil, history.History{}, err
+	}
 
-	if len(parsedIssues) > 0 {
-		err = os.MkdirAll(cfg.TempDir, os.ModePerm)
-		if err != nil {
-			return nil, err
+	outdated := 0
+	for i, issue := range postIssues {
+		parsedIssue := &parsedIssues[i]
+		metadata := parsedIssue.Metadata
+
+		linked := rewritePostLinks(
+			parsedIssue,
+			issue.GetHTMLURL(),
+			repositoryURL(*issue),
+			urls,
+		)
+
+		// The post only needs to be generated again if its content, the
+		// way it is rendered or the pages it links to changed, not on every
+		// comment or reaction.
+		number := issue.GetNumber()
+		hash := history.Hash(
+			[]byte(issue.GetBody()),
+			[]byte(fingerprint),
+			[]byte(linkedURLs(linked, urls)),
+		)
+		previous, ok := postsHistory.Lookup(number, metadata.Slug)
+		isUpToDate := ok && previous.Slug == metadata.Slug &&
+			previous.Hash == hash && !opts.Force
+
+		updatedAt := issue.GetUpdatedAt()
+		if !opts.selects(number, metadata.Slug) {
+			isUpToDate = true
+			hash = previous.Hash
+			updatedAt = previous.UpdatedAt
 		}
 
-		err = history.UpdateHistoryFile(postsHistory, cfg)
-		if err != nil {
-			return nil, err
+		// Up to date posts keep the files they were published with
+		outputFiles := previous.OutputFiles
+		if !isUpToDate {
+			outdated++
+
+			if cfg.SelfHostImages {
+				selfHostImages(parsedIssue, cfg, fetch)
+			}
+
+			outputFiles = []string{metadata.Slug + ".html"}
+			for _, asset := range parsedIssue.Assets {
+				outputFiles = append(outputFiles, asset.Path)
+			}
 		}
+
+		next.Set(number, history.Post{
+			Slug:        metadata.Slug,
+			Hash:        hash,
+			UpdatedAt:   updatedAt,
+			OutputFiles: outputFiles,
+		})
+
+		parsedIssue.Hash = hash
+		parsedIssue.IsUpToDate = isUpToDate
 	}
 
-	return parsedIssues, nil
+	log.Printf(
+		"Found %d blog posts, %d to generate.\n",
+		len(parsedIssues),
+		outdated,
+	)
+
+	return parsedIssues, next, nil
 }
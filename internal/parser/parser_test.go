@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -126,3 +128,161 @@ func TestExtractMetadata(t *testing.T) {
 		}
 	}
 }
+
+func TestOptionsSelects(t *testing.T) {
+	opts := Options{Only: []string{"test-post", "#42"}}
+
+	tests := []struct {
+		number   int
+		slug     string
+		expected bool
+	}{
+		{1, "test-post", true},
+		{42, "other-post", true},
+		{7, "other-post", false},
+	}
+
+	for _, test := range tests {
+		actual := opts.selects(test.number, test.slug)
+		if actual != test.expected {
+			t.Errorf(
+				"Expected selects(%d, %q) to be %t",
+				test.number,
+				test.slug,
+				test.expected,
+			)
+		}
+	}
+
+	if !(Options{}).selects(7, "other-post") {
+		t.Error("Expected empty Only to select every post")
+	}
+}
+
+func TestOptionsUnmatched(t *testing.T) {
+	opts := Options{Only: []string{"test-post", "#42", "missing", "7"}}
+	posts := []ParsedIssue{
+		{Number: 1, Metadata: Metadata{Slug: "test-post"}},
+		{Number: 42, Metadata: Metadata{Slug: "other-post"}},
+	}
+
+	expected := []string{"missing", "7"}
+	actual := opts.unmatched(posts)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v to match no post, got %v", expected, actual)
+	}
+}
+
+func TestParseLocalPosts(t *testing.T) {
+	postsDir := t.TempDir()
+	err := os.WriteFile(
+		filepath.Join(postsDir, "test-post.md"),
+		[]byte(*mockIssue().Body),
+		0644,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	posts, err := ParseLocalPosts(config.Config{
+		PostsDir:   postsDir,
+		WebsiteURL: "example.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(posts) != 1 {
+		t.Fatalf("Expected 1 post, got %d", len(posts))
+	}
+
+	if posts[0].Metadata.Slug != "test-post" {
+		t.Errorf(
+			"Expected slug to be test-post, got %s",
+			posts[0].Metadata.Slug,
+		)
+	}
+
+	heading := `<h1 id="test-post">Test post</h1>`
+	if !strings.Contains(string(posts[0].Content), heading) {
+		t.Errorf("Expected Markdown to be rendered, got %s", posts[0].Content)
+	}
+}
+
+func TestNewPostBody(t *testing.T) {
+	body := NewPostBody(`Test "post"`, "test-post")
+	parsedIssue, err := parseIssue(
+		github.Issue{Body: &body},
+		config.Config{WebsiteURL: "example.com"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsedIssue.Metadata.Title != `Test "post"` {
+		t.Errorf("Expected title to be kept, got %q", parsedIssue.Metadata.Title)
+	}
+
+	if parsedIssue.Metadata.Slug != "test-post" {
+		t.Errorf("Expected slug to be kept, got %q", parsedIssue.Metadata.Slug)
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	content := []byte("Line one\nLine two\n\nA ~~typo~~\n")
+
+	cfg := config.Config{}
+	rendered, err := renderMarkdown(content, "", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "<p>Line one\nLine two</p>\n\n<p>A <del>typo</del></p>\n"
+	if string(rendered.html) != expected {
+		t.Errorf("Expected %q, got %q", expected, rendered.html)
+	}
+
+	cfg.MarkdownExtensions = []string{"hard_line_break", "-strikethrough"}
+	rendered, err = renderMarkdown(content, "", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = "<p>Line one<br>\nLine two</p>\n\n<p>A ~~typo~~</p>\n"
+	if string(rendered.html) != expected {
+		t.Errorf("Expected %q, got %q", expected, rendered.html)
+	}
+
+	cfg.SyntaxHighlighting = true
+	code := []byte("```go\nreturn nil\n```\n")
+	rendered, err = renderMarkdown(code, "", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `<pre class="highlight"><code class="language-go">` +
+		`<span class="hl-keyword">return</span> ` +
+		`<span class="hl-literal">nil</span>` + "\n</code></pre>\n"
+	if string(rendered.html) != expected {
+		t.Errorf("Expected %q, got %q", expected, rendered.html)
+	}
+
+	cfg.Math = true
+	rendered, err = renderMarkdown([]byte("$x$\n\n$$\ny\n$$\n"), "", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	formulas := []string{"<math><semantics>", `<math display="block">`}
+	for _, math := range formulas {
+		if !strings.Contains(string(rendered.html), math) {
+			t.Errorf("Expected %s in %s", math, rendered.html)
+		}
+	}
+
+	cfg.MarkdownHTMLFlags = []string{"unknown"}
+	_, err = renderMarkdown(content, "", cfg)
+	if err == nil {
+		t.Error("Expected an error for an unknown HTML flag")
+	}
+}
@@ -0,0 +1,211 @@
+package parser
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"github.com/ByteBakersCo/babilema/internal/config"
+	"github.com/ByteBakersCo/babilema/internal/utils"
+)
+
+// Tags kept by the sanitizer, on top of cfg.AllowedHTMLTags. They cover what
+// the Markdown renderer produces, MathML formulas and the usual formatting
+// tags written by hand.
+var allowedTags = utils.Words(`a abbr b blockquote br caption cite code col
+	colgroup dd del details dfn div dl dt em figcaption figure h1 h2 h3 h4
+	h5 h6 hr i img input ins kbd li mark nav ol p picture pre q s samp
+	section small source span strong sub summary sup table tbody td tfoot
+	th thead time tr u ul var wbr
+	math semantics annotation merror mfrac mi mn mo mover mroot mrow mspace
+	msqrt mstyle msub msubsup msup mtable mtd mtext mtr munder munderover`)
+
+// Attributes kept by the sanitizer, on any tag, on top of
+// cfg.AllowedHTMLAttributes. Event handlers (on*) and style are not part of
+// them.
+var allowedAttrs = utils.Words(`id class title lang dir alt src srcset sizes
+	href width height loading decoding align colspan rowspan scope start
+	reversed type disabled checked open cite datetime rel target
+	display mathvariant fence accent accentunder linethickness columnalign
+	encoding`)
+
+// Attributes holding URLs, which must not run scripts (e.g. javascript:)
+var urlAttrs = utils.Words("href src cite")
+
+// Schemes of the URLs kept by the sanitizer. Relative URLs are kept too.
+var allowedSchemes = utils.Words("http https mailto tel")
+
+// Tags whose content is dropped along with them, unless they are allowed
+var droppedContentTags = utils.Words(`object template svg select`)
+
+// Tags whose content the tokenizer reads as raw text, up to their end tag (or
+// the end of the document for plaintext). Their content is dropped along with
+// them, even when written as self-closing tags, since it is not HTML we can
+// filter.
+var rawTextTags = utils.Words(`script style iframe noscript textarea title xmp
+	noembed noframes plaintext`)
+
+// sanitizer removes the tags and attributes which are not allowed from
+// HTML documents.
+type sanitizer struct {
+	tags  map[string]bool
+	attrs map[string]bool
+}
+
+func newSanitizer(cfg config.Config) sanitizer {
+	s := sanitizer{
+		tags:  make(map[string]bool),
+		attrs: make(map[string]bool),
+	}
+
+	for tag := range allowedTags {
+		s.tags[tag] = true
+	}
+
+	for _, tag := range cfg.AllowedHTMLTags {
+		s.tags[strings.ToLower(strings.TrimSpace(tag))] = true
+	}
+
+	for attr := range allowedAttrs {
+		s.attrs[attr] = true
+	}
+
+	for _, attr := range cfg.AllowedHTMLAttributes {
+		s.attrs[strings.ToLower(strings.TrimSpace(attr))] = true
+	}
+
+	return s
+}
+
+// isSafeURL tells whether the URL is relative or uses an allowed scheme.
+func isSafeURL(rawURL string) bool {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return false
+	}
+
+	return u.Scheme == "" || allowedSchemes[strings.ToLower(u.Scheme)]
+}
+
+// isSafeSrcset tells whether every image candidate of the srcset attribute
+// has a safe URL.
+func isSafeSrcset(srcset string) bool {
+	for _, candidate := range strings.Split(srcset, ",") {
+		fields := strings.Fields(candidate)
+		if len(fields) > 0 && !isSafeURL(fields[0]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// filterAttrs returns the allowed attributes of the token, without unsafe
+// URLs.
+func (s sanitizer) filterAttrs(token html.Token) []html.Attribute {
+	var attrs []html.Attribute
+	for _, attr := range token.Attr {
+		key := strings.ToLower(attr.Key)
+		switch {
+		case !s.attrs[key] || attr.Namespace != "":
+			continue
+		case urlAttrs[key] && !isSafeURL(attr.Val):
+			continue
+		case key == "srcset" && !isSafeSrcset(attr.Val):
+			continue
+		}
+
+		attrs = append(attrs, attr)
+	}
+
+	return attrs
+}
+
+// isAllowed tells whether the tag is kept. Inputs are only allowed as the
+// checkboxes of task lists.
+func (s sanitizer) isAllowed(token html.Token) bool {
+	if !s.tags[token.Data] {
+		return false
+	}
+
+	if token.Data != "input" {
+		return true
+	}
+
+	for _, attr := range token.Attr {
+		if attr.Key == "type" && strings.EqualFold(attr.Val, "checkbox") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sanitize returns the content without comments, disallowed tags (their text
+// is kept, except for scripts and the like), disallowed attributes and URLs
+// running scripts.
+func (s sanitizer) sanitize(content []byte) []byte {
+	// The tag whose content is being dropped, and how deep we are in it
+	var dropping string
+	depth := 0
+
+	return utils.RewriteTags(content, func(token *html.Token) bool {
+		if dropping != "" {
+			switch {
+			case token.Type == html.StartTagToken && token.Data == dropping:
+				depth++
+			case token.Type == html.EndTagToken && token.Data == dropping:
+				depth--
+			}
+
+			if depth == 0 {
+				dropping = ""
+			}
+
+			*token = html.Token{}
+
+			return true
+		}
+
+		switch token.Type {
+		case html.TextToken:
+			// Re-escaped, so that a stray < cannot become a tag once joined
+			// with the text following a dropped tag or comment
+			return true
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if s.isAllowed(*token) {
+				token.Attr = s.filterAttrs(*token)
+				return true
+			}
+
+			if rawTextTags[token.Data] ||
+				(token.Type == html.StartTagToken &&
+					droppedContentTags[token.Data]) {
+				dropping = token.Data
+				depth = 1
+			}
+
+		case html.EndTagToken:
+			if s.tags[token.Data] {
+				return true
+			}
+		}
+
+		// Comments, doctypes and disallowed tags are dropped
+		*token = html.Token{}
+
+		return true
+	})
+}
+
+// sanitizeHTML removes what could run scripts or break the page from the
+// rendered content, unless cfg.AllowUnsafeHTML is set.
+func sanitizeHTML(content []byte, cfg config.Config) []byte {
+	if cfg.AllowUnsafeHTML {
+		return content
+	}
+
+	return newSanitizer(cfg).sanitize(content)
+}
@@ -0,0 +1,83 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ByteBakersCo/babilema/internal/config"
+)
+
+func TestSanitizeHTML(t *testing.T) {
+	content := []byte(`<p onclick="steal()">Hi <b>there</b><!-- note --></p>
+<script>alert("script")</script><style>p { display: none }</style>
+<img src="x.png" onerror="alert(1)" srcset="javascript:alert(1) 2x">
+<a href="javascript:alert(1)">link</a> <a href="/post.html#top">ok</a>
+<form action="/"><input type="text"><input type="checkbox" checked></form>
+<iframe src="https://example.com/embed"></iframe>
+<math><mi mathvariant="normal">x</mi></math>`)
+
+	expected := `<p>Hi <b>there</b></p>
+
+<img src="x.png">
+<a>link</a> <a href="/post.html#top">ok</a>
+<input type="checkbox" checked="">
+
+<math><mi mathvariant="normal">x</mi></math>`
+
+	sanitized := sanitizeHTML(content, config.Config{})
+
+	if string(sanitized) != expected {
+		t.Errorf("Expected %s, got %s", expected, sanitized)
+	}
+
+	// Raw text is dropped along with its tag, it is not filtered
+	for _, content := range []string{
+		"<p>Hi\n<plaintext>\n<script>alert(1)</script></p>",
+		"<p>Hi\n<plaintext/>\n<script>alert(1)</script></p>",
+		"<p>Hi\n<xmp/><script>alert(1)</script></xmp></p>",
+	} {
+		sanitized = sanitizeHTML([]byte(content), config.Config{})
+
+		if strings.Contains(string(sanitized), "<script") ||
+			!strings.HasPrefix(string(sanitized), "<p>Hi\n") {
+			t.Errorf("Expected %q to be dropped, got %q", content, sanitized)
+		}
+	}
+
+	// Text around dropped tags and comments cannot form tags
+	for _, content := range []string{
+		"<div>\n<<x>img src=x onerror=alert(1)>\n</div>",
+		"<div>\n<<!-- -->img src=x onerror=alert(1)>\n</div>",
+	} {
+		sanitized = sanitizeHTML([]byte(content), config.Config{})
+
+		expected = "<div>\n&lt;img src=x onerror=alert(1)&gt;\n</div>"
+		if string(sanitized) != expected {
+			t.Errorf("Expected %q, got %q", expected, sanitized)
+		}
+	}
+
+	// Sites can allow more tags and attributes
+	cfg := config.Config{
+		AllowedHTMLTags:       []string{"iframe"},
+		AllowedHTMLAttributes: []string{"allowfullscreen"},
+	}
+
+	content = []byte(`<iframe src="https://example.com" allowfullscreen>` +
+		`</iframe>`)
+	sanitized = sanitizeHTML(content, cfg)
+
+	expected = `<iframe src="https://example.com" allowfullscreen="">` +
+		`</iframe>`
+	if string(sanitized) != expected {
+		t.Errorf("Expected %s, got %s", expected, sanitized)
+	}
+
+	cfg = config.Config{AllowUnsafeHTML: true}
+	content = []byte(`<script>alert(1)</script>`)
+	sanitized = sanitizeHTML(content, cfg)
+
+	if string(sanitized) != string(content) {
+		t.Errorf("Expected %s to be left as is, got %s", content, sanitized)
+	}
+}
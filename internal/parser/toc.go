@@ -0,0 +1,83 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+// TOCEntry is a heading of a post in its table of contents.
+type TOCEntry struct {
+	Title string
+
+	// The id attribute of the heading, to link to it with #ID
+	ID    string
+	Level int
+
+	// The headings of the section, one level deeper
+	Children []TOCEntry
+}
+
+// headingText returns the text of the heading, without its formatting.
+func headingText(heading *ast.Heading) string {
+	var text strings.Builder
+	ast.WalkFunc(heading, func(node ast.Node, entering bool) ast.WalkStatus {
+		switch node := node.(type) {
+		case *ast.Text:
+			text.Write(node.Literal)
+		case *ast.Code:
+			text.Write(node.Literal)
+		}
+
+		return ast.GoToNext
+	})
+
+	return strings.Join(strings.Fields(text.String()), " ")
+}
+
+// tableOfContents returns the headings of the document that have an id,
+// nested by level. Duplicate ids are numbered (e.g. "setup-1") so that every
+// entry links to its own heading.
+func tableOfContents(doc ast.Node) []TOCEntry {
+	var root TOCEntry
+	sections := []*TOCEntry{&root}
+	taken := make(map[string]bool)
+
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		heading, ok := node.(*ast.Heading)
+		if !entering || !ok || heading.IsTitleblock {
+			return ast.GoToNext
+		}
+
+		if heading.HeadingID == "" {
+			return ast.SkipChildren
+		}
+
+		id := heading.HeadingID
+		for n := 1; taken[id]; n++ {
+			id = heading.HeadingID + "-" + strconv.Itoa(n)
+		}
+
+		taken[id] = true
+		heading.HeadingID = id
+
+		// Close the sections of the same level or deeper
+		for len(sections) > 1 &&
+			sections[len(sections)-1].Level >= heading.Level {
+			sections = sections[:len(sections)-1]
+		}
+
+		parent := sections[len(sections)-1]
+		parent.Children = append(parent.Children, TOCEntry{
+			Title: headingText(heading),
+			ID:    id,
+			Level: heading.Level,
+		})
+		sections = append(sections, &parent.Children[len(parent.Children)-1])
+
+		return ast.SkipChildren
+	})
+
+	return root.Children
+}
@@ -0,0 +1,46 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ByteBakersCo/babilema/internal/config"
+)
+
+func TestTableOfContents(t *testing.T) {
+	content := []byte(`# Intro
+
+## Setup ` + "`go`" + `
+
+### Details
+
+## Setup go
+
+# Usage {#how-to}
+`)
+
+	rendered, err := renderMarkdown(content, "", config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []TOCEntry{
+		{Title: "Intro", ID: "intro", Level: 1, Children: []TOCEntry{
+			{Title: "Setup go", ID: "setup-go", Level: 2, Children: []TOCEntry{
+				{Title: "Details", ID: "details", Level: 3},
+			}},
+			{Title: "Setup go", ID: "setup-go-1", Level: 2},
+		}},
+		{Title: "Usage", ID: "how-to", Level: 1},
+	}
+	if !reflect.DeepEqual(rendered.toc, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, rendered.toc)
+	}
+
+	for _, id := range []string{"intro", "setup-go", "setup-go-1", "how-to"} {
+		if !strings.Contains(string(rendered.html), `id="`+id+`"`) {
+			t.Errorf("Expected a heading with id %s, got %s", id, rendered.html)
+		}
+	}
+}
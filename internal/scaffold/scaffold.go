@@ -0,0 +1,86 @@
+package scaffold
+
+import (
+	"embed"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/ByteBakersCo/babilema/internal/theme"
+)
+
+// The starter config file and GitHub Actions workflow
+//
+//go:embed all:starter
+var starter embed.FS
+
+// templatesDir is where the default config expects the templates to be.
+const templatesDir string = "templates"
+
+func writeFile(path string, content []byte, overwrite bool) error {
+	_, err := os.Stat(path)
+	if err == nil && !overwrite {
+		log.Println("Skipped (already exists):", path)
+		return nil
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(path, content, 0644)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Created:", path)
+
+	return nil
+}
+
+// copyFS writes every file of fsys to dir.
+func copyFS(fsys fs.FS, dir string, overwrite bool) error {
+	return fs.WalkDir(
+		fsys,
+		".",
+		func(path string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() {
+				return err
+			}
+
+			content, err := fs.ReadFile(fsys, path)
+			if err != nil {
+				return err
+			}
+
+			return writeFile(
+				filepath.Join(dir, filepath.FromSlash(path)),
+				content,
+				overwrite,
+			)
+		},
+	)
+}
+
+// Init writes a starter site to dir: a default config file, the default
+// theme's templates and CSS, and a GitHub Actions workflow generating the
+// blog. Existing files are only replaced if overwrite is set.
+func Init(dir string, overwrite bool) error {
+	files, err := fs.Sub(starter, "starter")
+	if err != nil {
+		return err
+	}
+
+	err = copyFS(files, dir, overwrite)
+	if err != nil {
+		return err
+	}
+
+	return copyFS(theme.Default, filepath.Join(dir, templatesDir), overwrite)
+}
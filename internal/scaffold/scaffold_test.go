@@ -0,0 +1,59 @@
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInit(t *testing.T) {
+	dir := t.TempDir()
+
+	err := Init(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		".babilema.toml",
+		filepath.Join(".github", "workflows", "babilema.yml"),
+		filepath.Join("templates", "post.html"),
+		filepath.Join("templates", "header.html"),
+		filepath.Join("templates", "footer.html"),
+		filepath.Join("templates", "index.html"),
+		filepath.Join("templates", "css", "style.css"),
+	}
+
+	for _, file := range expected {
+		if _, err := os.Stat(filepath.Join(dir, file)); err != nil {
+			t.Errorf("Expected %s to be created: %s", file, err)
+		}
+	}
+
+	// Existing files are kept unless overwrite is set
+	configPath := filepath.Join(dir, ".babilema.toml")
+	err = os.WriteFile(configPath, []byte("foo"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Init(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, _ := os.ReadFile(configPath)
+	if string(content) != "foo" {
+		t.Error("Expected existing config file to be kept")
+	}
+
+	err = Init(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, _ = os.ReadFile(configPath)
+	if string(content) == "foo" {
+		t.Error("Expected existing config file to be overwritten")
+	}
+}
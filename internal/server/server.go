@@ -0,0 +1,355 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ByteBakersCo/babilema/internal/config"
+	"github.com/ByteBakersCo/babilema/internal/generator"
+	"github.com/ByteBakersCo/babilema/internal/parser"
+	"github.com/ByteBakersCo/babilema/internal/utils"
+)
+
+const (
+	reloadPath   string        = "/__babilema/reload"
+	pollInterval time.Duration = 500 * time.Millisecond
+)
+
+const reloadScript string = `<script>
+new EventSource("` + reloadPath + `").onmessage = () => location.reload();
+</script>
+`
+
+type server struct {
+	cfg     config.Config
+	rootDir string
+
+	// URL path the blog is served at (cfg.WebsiteURL's path)
+	prefix string
+
+	// URL path of cfg.OutputDir, relative to prefix
+	outputPath string
+
+	// Held while building, so that pages are not served half-written
+	buildMu  sync.RWMutex
+	buildErr error
+
+	clientsMu sync.Mutex
+	clients   map[chan struct{}]bool
+}
+
+// Serve builds the blog from the local posts in cfg.PostsDir and serves it on
+// addr. The blog is rebuilt, and the browser reloaded, whenever a template, a
+// CSS, JS or static file or a post changes.
+func Serve(cfg config.Config, addr string) error {
+	websiteURL, err := url.Parse(cfg.WebsiteURL)
+	if err != nil {
+		return err
+	}
+
+	rootDir, err := utils.RootDir()
+	if err != nil {
+		return err
+	}
+
+	outputPath, err := utils.RelativeFilePath(cfg.OutputDir)
+	if err != nil {
+		return err
+	}
+
+	buildDir, err := os.MkdirTemp("", "babilema-serve-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(buildDir)
+
+	// The blog is never published, Babilema serves it from cfg.TempDir
+	cfg.TempDir = buildDir
+
+	s := &server{
+		cfg:     cfg,
+		rootDir: rootDir,
+		prefix:  strings.TrimSuffix(websiteURL.Path, "/"),
+		outputPath: strings.TrimSuffix(
+			path.Clean(filepath.ToSlash(outputPath)),
+			"/",
+		),
+		clients: make(map[chan struct{}]bool),
+	}
+
+	s.build()
+	go s.watch()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(reloadPath, s.handleReload)
+	mux.HandleFunc("/", s.handleFile)
+
+	log.Printf("Serving blog at http://%s%s/\n", addr, s.prefix)
+
+	return http.ListenAndServe(addr, mux)
+}
+
+func (s *server) build() {
+	s.buildMu.Lock()
+	defer s.buildMu.Unlock()
+
+	posts, err := parser.ParseLocalPosts(s.cfg)
+	if err == nil {
+		err = generator.Preview(posts, s.cfg)
+	}
+
+	if err != nil {
+		log.Println("Error building blog:", err)
+	} else {
+		log.Println("Blog built.")
+	}
+
+	s.buildErr = err
+}
+
+// snapshot returns the modification time and size of every watched file.
+func (s *server) snapshot() map[string]string {
+	paths := []string{
+		s.cfg.TemplatePostFilePath,
+		s.cfg.TemplateHeaderFilePath,
+		s.cfg.TemplateFooterFilePath,
+		s.cfg.TemplateIndexFilePath,
+		s.cfg.CSSDir,
+		s.cfg.JSDir,
+		s.cfg.PostsDir,
+		s.cfg.Theme,
+		s.cfg.StaticDir,
+	}
+
+	files := make(map[string]string)
+	for _, root := range paths {
+		filepath.WalkDir(
+			root,
+			func(path string, entry fs.DirEntry, err error) error {
+				if err != nil || entry.IsDir() {
+					return nil
+				}
+
+				info, err := entry.Info()
+				if err != nil {
+					return nil
+				}
+
+				modTime := info.ModTime().UnixNano()
+				files[path] = fmt.Sprint(modTime, info.Size())
+
+				return nil
+			},
+		)
+	}
+
+	return files
+}
+
+func hasChanged(previous map[string]string, current map[string]string) bool {
+	if len(previous) != len(current) {
+		return true
+	}
+
+	for path, state := range current {
+		if previous[path] != state {
+			return true
+		}
+	}
+
+	return false
+}
+
+// watch polls the watched files, it avoids depending on OS specific APIs.
+func (s *server) watch() {
+	previous := s.snapshot()
+	for range time.Tick(pollInterval) {
+		current := s.snapshot()
+		if !hasChanged(previous, current) {
+			continue
+		}
+
+		previous = current
+		log.Println("Change detected, rebuilding...")
+		s.build()
+		s.notify()
+	}
+}
+
+func (s *server) notify() {
+	s.clientsMu.Lock()
+	defer s.clientsMu.Unlock()
+
+	for client := range s.clients {
+		select {
+		case client <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (s *server) handleReload(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	client := make(chan struct{}, 1)
+	s.clientsMu.Lock()
+	s.clients[client] = true
+	s.clientsMu.Unlock()
+
+	defer func() {
+		s.clientsMu.Lock()
+		delete(s.clients, client)
+		s.clientsMu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-client:
+			fmt.Fprint(w, "data: reload\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// resolve returns the file to serve for the given URL path. Generated pages
+// are looked up in the build directory, anything else (CSS, images...) in
+// the public directories of the root directory.
+func (s *server) resolve(urlPath string) (string, bool) {
+	if urlPath != s.prefix && !strings.HasPrefix(urlPath, s.prefix+"/") {
+		return "", false
+	}
+
+	urlPath = path.Clean("/" + strings.TrimPrefix(urlPath, s.prefix))
+	for _, segment := range strings.Split(urlPath, "/") {
+		if strings.HasPrefix(segment, ".") {
+			return "", false
+		}
+	}
+
+	if urlPath == path.Clean(s.outputPath+"/") {
+		index := filepath.Base(s.cfg.TemplateIndexFilePath)
+		urlPath = path.Join(urlPath, index)
+	}
+
+	relativePath, ok := strings.CutPrefix(urlPath, s.outputPath+"/")
+	if ok {
+		file := filepath.Join(s.cfg.TempDir, filepath.FromSlash(relativePath))
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			return file, true
+		}
+	}
+
+	file := filepath.Join(s.rootDir, filepath.FromSlash(urlPath))
+	if !s.isPublic(file) {
+		return "", false
+	}
+
+	if info, err := os.Stat(file); err == nil && !info.IsDir() {
+		return file, true
+	}
+
+	return "", false
+}
+
+// isPublic tells whether the file is part of what the blog publishes or
+// links to: the output, CSS, JS, static and theme directories. Other files
+// of the repository (sources, config, workflows...) are not served.
+func (s *server) isPublic(file string) bool {
+	dirs := []string{
+		s.cfg.OutputDir,
+		s.cfg.CSSDir,
+		s.cfg.JSDir,
+		s.cfg.StaticDir,
+		s.cfg.Theme,
+	}
+
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+
+		relativePath, err := filepath.Rel(dir, file)
+		if err == nil && relativePath != ".." &&
+			!strings.HasPrefix(relativePath, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// injectReloadScript adds the live reload script at the end of the page body.
+func injectReloadScript(page []byte) []byte {
+	i := bytes.LastIndex(page, []byte("</body>"))
+	if i < 0 {
+		return append(page, reloadScript...)
+	}
+
+	injected := append([]byte{}, page[:i]...)
+	injected = append(injected, reloadScript...)
+
+	return append(injected, page[i:]...)
+}
+
+func (s *server) handleFile(w http.ResponseWriter, r *http.Request) {
+	s.buildMu.RLock()
+	defer s.buildMu.RUnlock()
+
+	if s.buildErr != nil {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(
+			w,
+			"<pre>Error building blog:\n%s</pre>\n%s",
+			html.EscapeString(s.buildErr.Error()),
+			reloadScript,
+		)
+		return
+	}
+
+	urlPath := r.URL.Path
+	if strings.HasSuffix(urlPath, "/") {
+		urlPath += filepath.Base(s.cfg.TemplateIndexFilePath)
+	}
+
+	file, ok := s.resolve(urlPath)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	if filepath.Ext(file) != ".html" {
+		http.ServeFile(w, r, file)
+		return
+	}
+
+	page, err := os.ReadFile(file)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(injectReloadScript(page))
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ByteBakersCo/babilema/internal/config"
+)
+
+func TestInjectReloadScript(t *testing.T) {
+	expected := "<body>foo" + reloadScript + "</body>"
+	actual := string(injectReloadScript([]byte("<body>foo</body>")))
+	if actual != expected {
+		t.Errorf("Expected %q, got %q", expected, actual)
+	}
+
+	expected = "foo" + reloadScript
+	actual = string(injectReloadScript([]byte("foo")))
+	if actual != expected {
+		t.Errorf("Expected %q, got %q", expected, actual)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	rootDir := t.TempDir()
+	buildDir := t.TempDir()
+
+	files := map[string]string{
+		filepath.Join(buildDir, "index.html"):               "index",
+		filepath.Join(buildDir, "foo.html"):                 "foo",
+		filepath.Join(rootDir, "templates", "css", "a.css"): "a{}",
+		filepath.Join(rootDir, ".git", "config"):            "secret",
+		filepath.Join(rootDir, "main.go"):                   "package main",
+		filepath.Join(rootDir, "blog", "cat.png"):           "cat",
+	}
+	for path, content := range files {
+		err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = os.WriteFile(path, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := &server{
+		cfg: config.Config{
+			TemplateIndexFilePath: filepath.Join(rootDir, "index.html"),
+			TempDir:               buildDir,
+			OutputDir:             filepath.Join(rootDir, "blog"),
+			CSSDir:                filepath.Join(rootDir, "templates", "css"),
+		},
+		rootDir:    rootDir,
+		prefix:     "/foo",
+		outputPath: "/blog",
+	}
+
+	tests := []struct {
+		urlPath  string
+		expected string
+	}{
+		{"/foo/blog", filepath.Join(buildDir, "index.html")},
+		{"/foo/blog/foo.html", filepath.Join(buildDir, "foo.html")},
+		{
+			"/foo/templates/css/a.css",
+			filepath.Join(rootDir, "templates", "css", "a.css"),
+		},
+		{"/foo/blog/cat.png", filepath.Join(rootDir, "blog", "cat.png")},
+		{"/foo/.git/config", ""},
+		{"/foo/main.go", ""},
+		{"/foo/blog/../../.git/config", ""},
+		{"/blog/foo.html", ""},
+		{"/foo/blog/bar.html", ""},
+	}
+
+	for _, test := range tests {
+		actual, _ := s.resolve(test.urlPath)
+		if actual != test.expected {
+			t.Errorf(
+				"Expected %s to resolve to %q, got %q",
+				test.urlPath,
+				test.expected,
+				actual,
+			)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package theme
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed default
+var defaultTheme embed.FS
+
+// Default is the theme compiled into Babilema: post.html, header.html,
+// footer.html, index.html and css/style.css.
+// Other themes are directories following the same layout, and may also ship
+// scripts in js/.
+var Default fs.FS
+
+func init() {
+	var err error
+	Default, err = fs.Sub(defaultTheme, "default")
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Names of the files of a theme
+const (
+	PostTemplate   string = "post.html"
+	HeaderTemplate string = "header.html"
+	FooterTemplate string = "footer.html"
+	IndexTemplate  string = "index.html"
+	CSSDir         string = "css"
+	JSDir          string = "js"
+)
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"bytes"
+
+	"golang.org/x/net/html"
+)
+
+// RewriteTags passes every token of the HTML content to rewrite. Tokens it
+// returns true for are written back as token.String(), with the changes it
+// made to them, the others as they were. A token reset to html.Token{} is
+// dropped.
+func RewriteTags(content []byte, rewrite func(token *html.Token) bool) []byte {
+	var out bytes.Buffer
+
+	// Reading from memory, the tokenizer only fails at the end of content
+	tokenizer := html.NewTokenizer(bytes.NewReader(content))
+	for tokenizer.Next() != html.ErrorToken {
+		// Token modifies the buffer returned by Raw
+		raw := append([]byte{}, tokenizer.Raw()...)
+		token := tokenizer.Token()
+		if rewrite(&token) {
+			out.WriteString(token.String())
+		} else {
+			out.Write(raw)
+		}
+	}
+
+	return out.Bytes()
+}
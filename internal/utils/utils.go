@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"unicode"
 )
 
 func RootDir() (string, error) {
@@ -53,3 +54,31 @@ func RelativeFilePath(path string) (string, error) {
 
 	return "/" + relativePath, nil
 }
+
+// Slugify turns a title into a lowercase, dash-separated slug
+// (e.g. "Hello, World!" -> "hello-world").
+func Slugify(title string) string {
+	var slug strings.Builder
+	isDash := true
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			slug.WriteRune(r)
+			isDash = false
+		} else if !isDash {
+			slug.WriteRune('-')
+			isDash = true
+		}
+	}
+
+	return strings.TrimSuffix(slug.String(), "-")
+}
+
+// Words returns a set of the space separated words of s.
+func Words(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(s) {
+		set[word] = true
+	}
+
+	return set
+}
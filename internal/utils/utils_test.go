@@ -4,6 +4,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"golang.org/x/net/html"
 )
 
 func TestRootDir(t *testing.T) {
@@ -28,3 +30,47 @@ func TestRelativeFilePath(t *testing.T) {
 		t.Errorf("Expected output to be %s, got %s", expected, actual)
 	}
 }
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"Hello, World!":       "hello-world",
+		"  Babilema -- v2.0 ": "babilema-v2-0",
+		"Été à Paris":         "été-à-paris",
+	}
+
+	for title, expected := range tests {
+		actual := Slugify(title)
+		if actual != expected {
+			t.Errorf("Expected %q to be %q, got %q", title, expected, actual)
+		}
+	}
+}
+
+func TestRewriteTags(t *testing.T) {
+	content := []byte(`<p class=x>Hi <a href='/a'>a</a><!-- note --></p>`)
+	expected := `<p class=x>Hi <a href="/b">a</a></p>`
+
+	actual := RewriteTags(content, func(token *html.Token) bool {
+		switch {
+		case token.Type == html.CommentToken:
+			*token = html.Token{}
+		case token.Data == "a" && token.Type == html.StartTagToken:
+			token.Attr[0].Val = "/b"
+		default:
+			return false
+		}
+
+		return true
+	})
+
+	if string(actual) != expected {
+		t.Errorf("Expected %s, got %s", expected, actual)
+	}
+}
+
+func TestWords(t *testing.T) {
+	set := Words(" foo\n\tbar  foo ")
+	if len(set) != 2 || !set["foo"] || !set["bar"] {
+		t.Errorf("Expected the set of foo and bar, got %v", set)
+	}
+}